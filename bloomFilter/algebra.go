@@ -0,0 +1,115 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"math"
+)
+
+// Union adds the elements of other to BF, so BF holds the elements of both. Both filters must have the same m, k and hash functions.
+// The error of BF grows with the number of elements of the union. Computational time: O(m)
+func (b *BloomFilter) Union(other *BloomFilter) error {
+	if err := b.compatible(other); err != nil {
+		return err
+	}
+	b.bits.InPlaceUnion(other.bits)
+	return nil
+}
+
+// Intersect keeps in BF only the bits also set in other, so BF holds the elements of both filters. Both filters must have the same
+// m, k and hash functions. Its error is at most the one of the union of both filters. Computational time: O(m)
+func (b *BloomFilter) Intersect(other *BloomFilter) error {
+	if err := b.compatible(other); err != nil {
+		return err
+	}
+	b.bits.InPlaceIntersection(other.bits)
+	return nil
+}
+
+// Equal returns true if BF and other have the same m, k, hash functions and bits. Computational time: O(m)
+func (b *BloomFilter) Equal(other *BloomFilter) bool {
+	if b.compatible(other) != nil {
+		return false
+	}
+	// A mapped bit array spans whole words, so only the first m bits of both bit arrays are compared
+	words, otherWords := b.bits.Bytes(), other.bits.Bytes()
+	last := wordsNeeded(b.m) - 1
+	for i := 0; i < last; i++ {
+		if words[i] != otherWords[i] {
+			return false
+		}
+	}
+	mask := utils.Mask(b.m - uint(last)*utils.Machine64Bits)
+	return words[last]&mask == otherWords[last]&mask
+}
+
+// EstimateCardinality returns an estimation of the number of distinct elements inserted into BF, derived from its set bits
+// (Swamidass and Baldi). Computational time: O(m)
+func (b *BloomFilter) EstimateCardinality() float64 {
+	return b.estimate(b.bits.Count())
+}
+
+// EstimateUnionCardinality returns an estimation of the number of distinct elements inserted into BF or other, derived from the set
+// bits of their union. Both filters must have the same m, k and hash functions. Computational time: O(m)
+func (b *BloomFilter) EstimateUnionCardinality(other *BloomFilter) (float64, error) {
+	if err := b.compatible(other); err != nil {
+		return 0, err
+	}
+	return b.estimate(b.bits.UnionCardinality(other.bits)), nil
+}
+
+// EstimateIntersectionCardinality returns an estimation of the number of distinct elements inserted into both BF and other,
+// as the sum of their cardinalities minus the one of their union. Both filters must have the same m, k and hash functions. Computational time: O(m)
+func (b *BloomFilter) EstimateIntersectionCardinality(other *BloomFilter) (float64, error) {
+	union, err := b.EstimateUnionCardinality(other)
+	if err != nil {
+		return 0, err
+	}
+	return math.Max(0, b.EstimateCardinality()+other.EstimateCardinality()-union), nil
+}
+
+// EstimateJaccard returns an estimation of the Jaccard similarity of the sets inserted into BF and other, the cardinality of their
+// intersection over the one of their union. It is 0 if both filters are empty. Both filters must have the same m, k and hash functions.
+// Computational time: O(m)
+func (b *BloomFilter) EstimateJaccard(other *BloomFilter) (float64, error) {
+	union, err := b.EstimateUnionCardinality(other)
+	if err != nil || union == 0 {
+		return 0, err
+	}
+	intersection := math.Max(0, b.EstimateCardinality()+other.EstimateCardinality()-union)
+	return math.Min(1, intersection/union), nil
+}
+
+// estimate returns the number of distinct elements of a BF with set bits set, -m/k*ln(1-set/m).
+// It is infinite when every bit is set
+func (b *BloomFilter) estimate(set uint) float64 {
+	m, k := float64(b.m), float64(b.k)
+	return -m / k * math.Log(1-float64(set)/m)
+}
+
+// compatible returns a *filter.IncompatibleError if other does not have the m, k and hash functions of BF
+func (b *BloomFilter) compatible(other *BloomFilter) error {
+	switch {
+	case b.m != other.m:
+		return incompatibleError("m", b.m, other.m)
+	case b.k != other.k:
+		return incompatibleError("k", b.k, other.k)
+	case b.seed != other.seed:
+		return incompatibleError("seed", b.seed, other.seed)
+	case b.doubleHashing != other.doubleHashing:
+		return incompatibleError("doubleHashing", b.doubleHashing, other.doubleHashing)
+	case !hasher.Equal(b.hasher, other.hasher):
+		return incompatibleError("hasher", hasher.AlgorithmOf(b.hasher), hasher.AlgorithmOf(other.hasher))
+	}
+	return nil
+}
+
+func incompatibleError(parameter string, value interface{}, other interface{}) error {
+	return &filter.IncompatibleError{
+		Filter:    "bloomFilter",
+		Parameter: parameter,
+		Value:     value,
+		Other:     other,
+	}
+}
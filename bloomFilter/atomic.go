@@ -0,0 +1,157 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"encoding"
+	"io"
+	"sync/atomic"
+)
+
+var (
+	_ filter.BatchFilter         = (*AtomicBloomFilter)(nil)
+	_ container.Storable         = (*AtomicBloomFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*AtomicBloomFilter)(nil)
+	_ io.WriterTo                = (*AtomicBloomFilter)(nil)
+	_ io.ReaderFrom              = (*AtomicBloomFilter)(nil)
+)
+
+// AtomicBloomFilter is a Bloom Filter whose bits are set and tested with atomic operations, so any number of
+// goroutines can insert and look up elements without locks. It has the same sizing and encoding as BloomFilter
+type AtomicBloomFilter struct {
+	// filter holds the parameters of the BF. Its bit array is not used
+	filter BloomFilter
+	// words is the bit array. It is allocated by make, so every word is 64-bit aligned even in 32-bit machines
+	words []uint64
+	// parallelism is the number of goroutines the batch operations fan out to
+	parallelism int
+}
+
+// NewAtomic creates a new Atomic Bloom Filter that can hold n elements with e false positive error, configured with opts
+func NewAtomic(n uint, e float64, opts ...Option) (*AtomicBloomFilter, error) {
+	b, err := NewWithOptions(n, e, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewAtomicFrom(b), nil
+}
+
+// NewAtomicWithSize creates a new Atomic Bloom Filter with size m and k hash functions, configured with opts
+func NewAtomicWithSize(m uint, k uint, opts ...Option) (*AtomicBloomFilter, error) {
+	b, err := NewWithSize(m, k, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewAtomicFrom(b), nil
+}
+
+// NewAtomicFrom creates an Atomic Bloom Filter holding the elements of b, taking over its bit array. b must not be used afterwards
+func NewAtomicFrom(b *BloomFilter) *AtomicBloomFilter {
+	a := &AtomicBloomFilter{filter: *b, words: b.bits.Bytes()}
+	a.filter.bits = nil
+	return a
+}
+
+// SetParallelism sets the number of goroutines InsertBatch and LookupBatch split their elements between. By default they do not fan out.
+// It must be called before sharing the filter
+func (a *AtomicBloomFilter) SetParallelism(goroutines int) {
+	a.parallelism = goroutines
+}
+
+// Insert inserts element into BF. Always returns true, as a BF cannot fail on insert. Computational time: O(k)
+func (a *AtomicBloomFilter) Insert(element []byte) bool {
+	p := a.filter.positions(element)
+	for i := uint(0); i < a.filter.k; i++ {
+		pos := p.Next()
+		orUint64(&a.words[pos/utils.Machine64Bits], 1<<(pos%utils.Machine64Bits))
+	}
+	return true
+}
+
+// Lookup returns true if element may belong to the BF and false if element does not belong to the BF. Computational time: O(k)
+func (a *AtomicBloomFilter) Lookup(element []byte) bool {
+	p := a.filter.positions(element)
+	for i := uint(0); i < a.filter.k; i++ {
+		pos := p.Next()
+		if atomic.LoadUint64(&a.words[pos/utils.Machine64Bits])&(1<<(pos%utils.Machine64Bits)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// InsertBatch inserts elements into BF, splitting them between the goroutines set by SetParallelism. Always returns nil
+func (a *AtomicBloomFilter) InsertBatch(elements [][]byte) error {
+	utils.ParallelChunks(len(elements), a.parallelism, func(start int, end int) {
+		a.filter.forEachSortedPosition(elements[start:end], func(pos uint, _ int) {
+			orUint64(&a.words[pos/utils.Machine64Bits], 1<<(pos%utils.Machine64Bits))
+		})
+	})
+	return nil
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in BF, splitting the elements between the goroutines set by SetParallelism
+func (a *AtomicBloomFilter) LookupBatch(elements [][]byte, results []bool) {
+	utils.ParallelChunks(len(elements), a.parallelism, func(start int, end int) {
+		chunk := results[start:end]
+		for i := range chunk {
+			chunk[i] = true
+		}
+		a.filter.forEachSortedPosition(elements[start:end], func(pos uint, i int) {
+			if chunk[i] && atomic.LoadUint64(&a.words[pos/utils.Machine64Bits])&(1<<(pos%utils.Machine64Bits)) == 0 {
+				chunk[i] = false
+			}
+		})
+	})
+}
+
+// TotalSize returns an estimation (in bytes) of the size of the array that represents BF.
+func (a *AtomicBloomFilter) TotalSize() uint {
+	return a.filter.TotalSize()
+}
+
+// Descriptor returns the description of BF written in the header of a container
+func (a *AtomicBloomFilter) Descriptor() container.Descriptor {
+	return a.filter.Descriptor()
+}
+
+// MarshalBinary encodes a snapshot of BF with the encoding of BloomFilter, so it can be decoded by either type.
+// Elements inserted while encoding may or may not be part of the snapshot
+func (a *AtomicBloomFilter) MarshalBinary() ([]byte, error) {
+	words := make([]uint64, len(a.words))
+	for i := range a.words {
+		words[i] = atomic.LoadUint64(&a.words[i])
+	}
+	return a.filter.encode(words)
+}
+
+// UnmarshalBinary decodes a BF encoded by BloomFilter or AtomicBloomFilter, replacing the content of a. It must not be used concurrently
+func (a *AtomicBloomFilter) UnmarshalBinary(data []byte) error {
+	filter, words, err := decode(data)
+	if err != nil {
+		return err
+	}
+	a.filter, a.words = filter, words
+	return nil
+}
+
+// WriteTo writes the binary encoding of BF to w
+func (a *AtomicBloomFilter) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoding(w, a)
+}
+
+// ReadFrom reads a binary encoding of a BF from r, replacing the content of a. It must not be used concurrently
+func (a *AtomicBloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	return readEncoding(r, a)
+}
+
+// orUint64 atomically sets the bits of mask in *addr with a compare-and-swap loop
+func orUint64(addr *uint64, mask uint64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if old&mask == mask || atomic.CompareAndSwapUint64(addr, old, old|mask) {
+			return
+		}
+	}
+}
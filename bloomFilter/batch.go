@@ -0,0 +1,64 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+)
+
+const (
+	// batchChunkBits is the log2 of the number of elements whose positions are sorted together by the batch operations
+	batchChunkBits = 12
+	batchChunk     = 1 << batchChunkBits
+	// batchRegions is the number of regions of the bit array the positions of a chunk are grouped by
+	batchRegions = 1024
+)
+
+var _ filter.BatchFilter = (*BloomFilter)(nil)
+
+// InsertBatch inserts elements into BF, setting their bits in increasing order. Always returns nil, as a BF cannot fail on insert
+func (b *BloomFilter) InsertBatch(elements [][]byte) error {
+	b.forEachSortedPosition(elements, func(pos uint, _ int) {
+		b.bits.Set(pos)
+	})
+	return nil
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in BF, testing their bits in increasing order
+func (b *BloomFilter) LookupBatch(elements [][]byte, results []bool) {
+	results = results[:len(elements)]
+	for i := range results {
+		results[i] = true
+	}
+	b.forEachSortedPosition(elements, func(pos uint, i int) {
+		if results[i] && !b.bits.Test(pos) {
+			results[i] = false
+		}
+	})
+}
+
+// forEachSortedPosition calls fn with the k positions of every element and the index of the element. Elements are
+// hashed in chunks whose positions are visited grouped by region of the bit array, in increasing order of region,
+// so the bit array is scanned mostly sequentially. It only reads the parameters of b, so it can run concurrently
+func (b *BloomFilter) forEachSortedPosition(elements [][]byte, fn func(pos uint, i int)) {
+	keys := make([]uint64, 0, batchChunk*b.k)
+	sorted := make([]uint64, batchChunk*b.k)
+	counts := make([]int, batchRegions+1)
+	shift := utils.RegionShift(uint64(b.m-1)<<batchChunkBits|(batchChunk-1), batchRegions)
+	for start := 0; start < len(elements); start += batchChunk {
+		end := start + batchChunk
+		if end > len(elements) {
+			end = len(elements)
+		}
+		keys = keys[:0]
+		for i := start; i < end; i++ {
+			p := b.positions(elements[i])
+			for j := uint(0); j < b.k; j++ {
+				keys = append(keys, uint64(p.Next())<<batchChunkBits|uint64(i-start))
+			}
+		}
+		utils.RegionSort(keys, sorted, shift, counts)
+		for _, key := range sorted[:len(keys)] {
+			fn(uint(key>>batchChunkBits), start+int(key&(batchChunk-1)))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"math/bits"
+)
+
+const (
+	// blockBits is the size of every block of a BBF, a 64-byte cache line
+	blockBits  = 512
+	blockWords = blockBits / utils.Machine64Bits
+)
+
+// blockSalts are the odd multipliers that map the hash of an element to a bit of each word of a block when using word masks
+var blockSalts = [blockWords]uint64{
+	0x47b6137b44974d91, 0x8824ad5ba2b7289d, 0x705495c72df1424b, 0x9efc49475c6bfb31,
+	0x2c5a4d6b9e7f1e03, 0x5f356495ad5f7b1d, 0xe8fa3a8b2f1c4d6f, 0x1b873593cc9e2d51,
+}
+
+var _ filter.Filter = (*BlockedBloomFilter)(nil)
+
+// BlockedBloomFilter is a Bloom Filter whose bits are split in blocks of 512 bits (a cache line). One hash selects the block
+// of an element and its k bits are set within it, so every operation touches a single cache line. Its false positive
+// error is slightly higher than the one of a BloomFilter of the same size, as the blocks are not evenly loaded
+type BlockedBloomFilter struct {
+	// filter holds the parameters of the BF, m being rounded up to whole blocks. Its bit array is not used
+	filter    BloomFilter
+	wordMasks bool
+	blocks    uint64
+	words     []uint64
+}
+
+// NewBlocked creates a new Blocked Bloom Filter with the size and hash functions of a BF that can hold n elements with e false positive error,
+// configured with opts
+func NewBlocked(n uint, e float64, opts ...Option) (*BlockedBloomFilter, error) {
+	if err := validateCapacityAndError(n, e); err != nil {
+		return nil, err
+	}
+	sizeM, sizeK := OptimalSize(n, e)
+	return newBlocked(n, e, sizeM, sizeK, opts)
+}
+
+// NewBlockedWithSize creates a new Blocked Bloom Filter with size m, rounded up to whole blocks, and k hash functions, configured with opts
+func NewBlockedWithSize(m uint, k uint, opts ...Option) (*BlockedBloomFilter, error) {
+	if err := validateSizeAndHashes(m, k); err != nil {
+		return nil, err
+	}
+	n, e := Capacity(m, k)
+	return newBlocked(n, e, m, k, opts)
+}
+
+// Insert inserts element into BBF. Always returns true, as a BBF cannot fail on insert. Computational time: O(k)
+func (b *BlockedBloomFilter) Insert(element []byte) bool {
+	block, h := b.hash(element)
+	if b.wordMasks {
+		masks := b.masks(h)
+		for w := range masks {
+			block[w] |= masks[w]
+		}
+		return true
+	}
+	x, y := h, h>>32|1
+	for i := uint(0); i < b.filter.k; i++ {
+		pos := x % blockBits
+		block[pos/utils.Machine64Bits] |= 1 << (pos % utils.Machine64Bits)
+		x += y
+	}
+	return true
+}
+
+// Lookup returns true if element may belong to the BBF and false if element does not belong to the BBF. Computational time: O(k)
+func (b *BlockedBloomFilter) Lookup(element []byte) bool {
+	block, h := b.hash(element)
+	if b.wordMasks {
+		masks := b.masks(h)
+		missing := uint64(0)
+		for w := range masks {
+			missing |= masks[w] &^ block[w]
+		}
+		return missing == 0
+	}
+	x, y := h, h>>32|1
+	for i := uint(0); i < b.filter.k; i++ {
+		pos := x % blockBits
+		if block[pos/utils.Machine64Bits]&(1<<(pos%utils.Machine64Bits)) == 0 {
+			return false
+		}
+		x += y
+	}
+	return true
+}
+
+// TotalSize returns the size (in bytes) of the blocks that represent BBF.
+func (b *BlockedBloomFilter) TotalSize() uint {
+	return uint(len(b.words)) * utils.WordSize
+}
+
+func newBlocked(n uint, e float64, m uint, k uint, opts []Option) (*BlockedBloomFilter, error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	blocks := (m + blockBits - 1) / blockBits
+	b := &BlockedBloomFilter{
+		filter: BloomFilter{
+			n:      n,
+			e:      e,
+			m:      blocks * blockBits,
+			k:      k,
+			seed:   cfg.seed,
+			hasher: cfg.hasher,
+		},
+		wordMasks: cfg.wordMasks,
+		blocks:    uint64(blocks),
+		words:     make([]uint64, blocks*blockWords),
+	}
+	return b, nil
+}
+
+// hash returns the block of element and the hash its bits inside the block are derived from
+func (b *BlockedBloomFilter) hash(element []byte) ([]uint64, uint64) {
+	h1, h2 := b.filter.hasher.Sum128(element, b.filter.seed)
+	// Multiply-shift maps h1 to a block without a division
+	hi, _ := bits.Mul64(h1, b.blocks)
+	return b.words[hi*blockWords : (hi+1)*blockWords], h2
+}
+
+// masks returns the bits of the element whose hash is h in every word of its block: the i-th bit in the (s+i mod 8)-th word,
+// s being chosen by h so that all the words are equally loaded when k < 8, at the position given by the highest 6 bits of h times a salt
+func (b *BlockedBloomFilter) masks(h uint64) [blockWords]uint64 {
+	var masks [blockWords]uint64
+	start := uint(h % blockWords)
+	for i := uint(0); i < b.filter.k; i++ {
+		w := (start + i) % blockWords
+		round := h ^ uint64(i/blockWords)*0x9e3779b97f4a7c15
+		masks[w] |= 1 << ((round * blockSalts[w]) >> 58)
+	}
+	return masks
+}
@@ -1,49 +1,80 @@
 package bloomFilter
 
 import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
 	"ProbabilisticDataStructures/utils"
 	"math"
 
 	"github.com/bits-and-blooms/bitset"
-	"github.com/spaolacci/murmur3"
 )
 
+var _ filter.Filter = (*BloomFilter)(nil)
+
 // BloomFilter is the struct that represents a Bloom Filter
 type BloomFilter struct {
-	n     uint
-	m     uint
-	k     uint
-	e     float64
-	bits *bitset.BitSet
+	n      uint
+	m      uint
+	k      uint
+	e      float64
+	seed   uint32
+	hasher hasher.Hasher
+	// doubleHashing derives the k positions from a single 128-bit hash
+	doubleHashing bool
+	bits          *bitset.BitSet
 }
 
-// New creates a new Bloom Filter with size m and k hash functions
+// New creates a new Bloom Filter with size m and k hash functions. It panics if m or k are 0, NewWithSize returns an error instead
 func New(m uint, k uint) BloomFilter {
-	n := computeCapacity(m, k)
-	e := computeError(m, k, n)
-	return newBF(n, e, m, k)
+	b, err := NewWithSize(m, k)
+	if err != nil {
+		panic(err)
+	}
+	return *b
 }
 
-// NewFromSizeAndError creates a new Bloom Filter that can hold n elements with e false positive error
+// NewFromSizeAndError creates a new Bloom Filter that can hold n elements with e false positive error.
+// It panics if n is 0 or e is not between 0 and 1, NewWithOptions returns an error instead
 func NewFromSizeAndError(n uint, e float64) BloomFilter {
-	sizeM := computeSizeM(n, e)
-	sizeK := computeSizeK(n, sizeM)
-	return newBF(n, e, sizeM, sizeK)
+	b, err := NewWithOptions(n, e)
+	if err != nil {
+		panic(err)
+	}
+	return *b
+}
+
+// NewWithOptions creates a new Bloom Filter that can hold n elements with e false positive error, configured with opts
+func NewWithOptions(n uint, e float64, opts ...Option) (*BloomFilter, error) {
+	if err := validateCapacityAndError(n, e); err != nil {
+		return nil, err
+	}
+	sizeM, sizeK := OptimalSize(n, e)
+	return newBF(n, e, sizeM, sizeK, opts)
+}
+
+// NewWithSize creates a new Bloom Filter with size m and k hash functions, configured with opts
+func NewWithSize(m uint, k uint, opts ...Option) (*BloomFilter, error) {
+	if err := validateSizeAndHashes(m, k); err != nil {
+		return nil, err
+	}
+	n, e := Capacity(m, k)
+	return newBF(n, e, m, k, opts)
 }
 
-// Insert inserts element into BF. Computational time: O(k)
-func (b *BloomFilter) Insert(element []byte) {
-	positions := b.computeKHashPositions(element)
-	for _, pos := range positions {
-		b.bits.Set(pos)
+// Insert inserts element into BF. Always returns true, as a BF cannot fail on insert. Computational time: O(k)
+func (b *BloomFilter) Insert(element []byte) bool {
+	p := b.positions(element)
+	for i := uint(0); i < b.k; i++ {
+		b.bits.Set(p.Next())
 	}
+	return true
 }
 
 // Lookup returns true if element may belong to the BF and false if element does not belong to the BF. Computational time: O(k)
-func (b BloomFilter) Lookup(element []byte) bool {
-	positions := b.computeKHashPositions(element)
-	for _, pos := range positions {
-		if !b.bits.Test(pos) {
+func (b *BloomFilter) Lookup(element []byte) bool {
+	p := b.positions(element)
+	for i := uint(0); i < b.k; i++ {
+		if !b.bits.Test(p.Next()) {
 			return false
 		}
 	}
@@ -51,34 +82,107 @@ func (b BloomFilter) Lookup(element []byte) bool {
 }
 
 // TotalSize returns an estimation (in bytes) of the size of the array that represents BF.
-func (b BloomFilter) TotalSize() uint {
+func (b *BloomFilter) TotalSize() uint {
 	if b.m % utils.ByteSize == 0 {
 		return b.m/utils.ByteSize
 	}
 	return b.m/utils.ByteSize + 1
 }
 
-func newBF(n uint, e float64, m uint, k uint) BloomFilter {
-	return BloomFilter{
-		n:    n,
-		e:    e,
-		m:    m,
-		k:    k,
-		bits: bitset.New(m),
+func validateCapacityAndError(n uint, e float64) error {
+	if n == 0 {
+		return parameterError("n", n, "capacity must be greater than 0")
+	}
+	if !(e > 0 && e < 1) {
+		return parameterError("e", e, "false positive error must be between 0 and 1 (exclusive)")
 	}
+	return nil
 }
 
-func (b BloomFilter) computeKHashPositions(element []byte) []uint {
-	positions := make([]uint, 0)
-	for i := uint(0); i < b.k; i++ {
-		pos := computeHash(element, uint32(i)) % b.m
-		positions = append(positions, pos)
+func validateSizeAndHashes(m uint, k uint) error {
+	if m == 0 {
+		return parameterError("m", m, "size must be greater than 0")
+	}
+	if k == 0 {
+		return parameterError("k", k, "number of hash functions must be greater than 0")
 	}
-	return positions
+	return nil
 }
 
-func computeHash(element []byte, seed uint32) uint {
-	return uint(murmur3.Sum64WithSeed(element, seed))
+func newBF(n uint, e float64, m uint, k uint, opts []Option) (*BloomFilter, error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return newBFWithConfig(n, e, m, k, cfg), nil
+}
+
+func newBFWithConfig(n uint, e float64, m uint, k uint, cfg config) *BloomFilter {
+	return &BloomFilter{
+		n:             n,
+		e:             e,
+		m:             m,
+		k:             k,
+		seed:          cfg.seed,
+		hasher:        cfg.hasher,
+		doubleHashing: cfg.doubleHashing,
+		bits:          bitset.New(m),
+	}
+}
+
+// positions returns the iterator over the k positions of element. It does not allocate
+func (b *BloomFilter) positions(element []byte) Positions {
+	return NewPositions(element, b.m, b.seed, b.hasher, b.doubleHashing)
+}
+
+// OptimalSize returns the size m and the number of hash functions k of a BF that can hold n elements with e false positive error
+func OptimalSize(n uint, e float64) (uint, uint) {
+	m := computeSizeM(n, e)
+	return m, computeSizeK(n, m)
+}
+
+// Capacity returns the number of elements n a BF of size m with k hash functions can hold and its false positive error when holding them
+func Capacity(m uint, k uint) (uint, float64) {
+	n := computeCapacity(m, k)
+	return n, computeError(m, k, n)
+}
+
+// NewPositions returns the iterator over the positions of element in a BF of size m with hash function h and seed, so filters
+// built on the positions of a BF, such as the counting one, use the same ones. It does not allocate
+func NewPositions(element []byte, m uint, seed uint32, h hasher.Hasher, doubleHashing bool) Positions {
+	p := Positions{element: element, m: uint64(m), seed: seed, hasher: h, doubleHashing: doubleHashing}
+	if doubleHashing {
+		h1, h2 := h.Sum128(element, seed)
+		p.x, p.y = h1%p.m, h2%p.m
+	}
+	return p
+}
+
+// Positions iterates over the positions of an element. With double hashing the i-th position is
+// h1 + i*h2 + (i^3-i)/6, computed incrementally modulo m, otherwise it is the hash of the element with seed+i
+type Positions struct {
+	element       []byte
+	m             uint64
+	seed          uint32
+	hasher        hasher.Hasher
+	doubleHashing bool
+	i             uint
+	x             uint64
+	y             uint64
+}
+
+// Next returns the next position of the element
+func (p *Positions) Next() uint {
+	if !p.doubleHashing {
+		pos := p.hasher.Sum64(p.element, p.seed+uint32(p.i)) % p.m
+		p.i++
+		return uint(pos)
+	}
+	pos := p.x
+	p.i++
+	p.x = (p.x + p.y) % p.m
+	p.y = (p.y + uint64(p.i)) % p.m
+	return uint(pos)
 }
 
 func computeCapacity(m uint, k uint) uint {
@@ -1,10 +1,14 @@
 package bloomFilter
 
 import (
+	"ProbabilisticDataStructures/filter"
 	"ProbabilisticDataStructures/utils"
 	"encoding/csv"
 	"fmt"
+	"math"
 	"os"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 )
@@ -376,4 +380,400 @@ func TestBitsPerSlotInFunctionOfErrorRate(t *testing.T) {
 	}
 	w.Flush()
 	resultsFile.Close()
-}
\ No newline at end of file
+}
+func TestThroughputWithDoubleHashing(t *testing.T) {
+	usernames, err := utils.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint(len(usernames))
+	errorRates := []float64{0.03, 0.001, 0.0001}
+	results := make([][]string, len(errorRates))
+	for k, e := range errorRates {
+		results[k] = []string{fmt.Sprint(e)}
+		for _, opts := range [][]Option{nil, {WithDoubleHashing()}} {
+			var insert, lookup int64
+			for i := 0; i < arithmeticMean; i++ {
+				f, err := NewWithOptions(n, e, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				start := time.Now()
+				for _, user := range usernames {
+					f.Insert(user)
+				}
+				insert += time.Since(start).Nanoseconds()
+				start = time.Now()
+				for _, user := range usernames {
+					if ok := f.Lookup(user); !ok {
+						t.Fatal("element should be in")
+					}
+				}
+				lookup += time.Since(start).Nanoseconds()
+			}
+			results[k] = append(results[k], fmt.Sprint(insert/arithmeticMean/int64(n)), fmt.Sprint(lookup/arithmeticMean/int64(n)))
+		}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/BF_DoubleHashing_Throughput_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"error", "insert", "lookup", "insert_double_hashing", "lookup_double_hashing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+func TestFPRateWithDoubleHashing(t *testing.T) {
+	usernames, err := utils.ReadDatasetFromCsvAndFixLengthTo150k()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint(len(usernames))
+	lookupDataset, _ := utils.CreateDataset()
+	results := make([][]string, len(proofs))
+	for k, pr := range proofs {
+		results[k] = []string{fmt.Sprint(pr.e)}
+		for _, opts := range [][]Option{nil, {WithDoubleHashing()}} {
+			f, err := NewWithOptions(n, pr.e, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, user := range usernames {
+				f.Insert(user)
+			}
+			falsePositives := 0
+			for _, elem := range lookupDataset {
+				if ok := f.Lookup(elem); ok {
+					falsePositives++
+				}
+			}
+			// The theoretical bound plus three standard deviations of the number of false positives
+			theoretical := computeError(f.m, f.k, n) * float64(len(lookupDataset))
+			if float64(falsePositives) > theoretical+3*math.Sqrt(theoretical)+1 {
+				t.Errorf("Error (e = %v, double hashing %t): Expected at most %.0f false positives and current false positives are %d", pr.e, f.doubleHashing, theoretical, falsePositives)
+			}
+			results[k] = append(results[k], fmt.Sprint(float64(falsePositives)/float64(len(lookupDataset))))
+		}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/BF_DoubleHashing_FP_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"error", "fp", "fp_double_hashing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+func TestThroughputConcurrentInsert(t *testing.T) {
+	usernames, err := utils.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint(len(usernames))
+	e := 0.001
+	var results [][]string
+	for goroutines := 1; goroutines <= 2*runtime.GOMAXPROCS(0); goroutines *= 2 {
+		var locked, atomic int64
+		for i := 0; i < arithmeticMean; i++ {
+			c := NewFromSizeAndError(n, e)
+			locked += insertInParallel(NewConcurrent(&c), usernames, goroutines)
+			a, err := NewAtomic(n, e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			atomic += insertInParallel(a, usernames, goroutines)
+		}
+		results = append(results, []string{fmt.Sprint(goroutines), fmt.Sprint(locked / arithmeticMean), fmt.Sprint(atomic / arithmeticMean)})
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/BF_Concurrent_Insert_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"goroutines", "rwmutex", "atomic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+// insertInParallel inserts elements into f splitting them between goroutines and returns the elapsed nanoseconds
+func insertInParallel(f filter.Filter, elements [][]byte, goroutines int) int64 {
+	var wg sync.WaitGroup
+	start := time.Now()
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(elements); i += goroutines {
+				f.Insert(elements[i])
+			}
+		}(g)
+	}
+	wg.Wait()
+	return time.Since(start).Nanoseconds()
+}
+
+func TestThroughputLookupBatch(t *testing.T) {
+	usernames, err := utils.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint(len(usernames))
+	errorRates := []float64{0.03, 0.001, 0.0001}
+	results := make([][]string, len(errorRates))
+	for k, e := range errorRates {
+		var single, batch int64
+		for i := 0; i < arithmeticMean; i++ {
+			f := NewFromSizeAndError(n, e)
+			if err := f.InsertBatch(usernames); err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			for _, user := range usernames {
+				if ok := f.Lookup(user); !ok {
+					t.Fatal("element should be in")
+				}
+			}
+			single += time.Since(start).Nanoseconds()
+			found := make([]bool, n)
+			start = time.Now()
+			f.LookupBatch(usernames, found)
+			batch += time.Since(start).Nanoseconds()
+			for _, ok := range found {
+				if !ok {
+					t.Fatal("element should be in")
+				}
+			}
+		}
+		results[k] = []string{fmt.Sprint(e), fmt.Sprint(single / arithmeticMean / int64(n)), fmt.Sprint(batch / arithmeticMean / int64(n))}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/BF_LookupBatch_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"error", "lookup", "lookup_batch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+func TestFPRateWhileInsertingScalable(t *testing.T) {
+	usernames, err := utils.ReadDatasetFromCsvAndFixLengthTo150k()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both filters expect a tenth of the dataset: the BF overflows while the SBF grows
+	n := uint(len(usernames) / 10)
+	bunch := 10000
+	lookupDataset, _ := utils.CreateRandomDataset()
+	var results [][]string
+	for _, pr := range proofs {
+		b := NewFromSizeAndError(n, pr.e)
+		s, err := NewScalable(n, pr.e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for insertPoint := 0; insertPoint < len(usernames); {
+			for aux := 0; aux < bunch && insertPoint < len(usernames); aux++ {
+				b.Insert(usernames[insertPoint])
+				s.Insert(usernames[insertPoint])
+				insertPoint++
+			}
+			row := []string{fmt.Sprint(pr.e), fmt.Sprint(insertPoint)}
+			for _, f := range []filter.Filter{&b, s} {
+				falsePositives := 0
+				for _, elem := range lookupDataset {
+					if ok := f.Lookup(elem); ok {
+						falsePositives++
+					}
+				}
+				row = append(row, fmt.Sprint(float64(falsePositives)/float64(len(lookupDataset))))
+			}
+			results = append(results, row)
+		}
+		if s.Error() > pr.e {
+			t.Errorf("Error: Expected the compound error of the SBF to stay below %v and current error is %v", pr.e, s.Error())
+		}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/SBF_FP_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"error", "inserted", "fp", "fp_scalable"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+func TestThroughputBlocked(t *testing.T) {
+	usernames, err := utils.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint(len(usernames))
+	results := make([][]string, len(proofs))
+	for k, pr := range proofs {
+		results[k] = []string{fmt.Sprint(pr.e)}
+		for _, newFilter := range blockedVariants() {
+			var insert, lookup int64
+			for i := 0; i < arithmeticMean; i++ {
+				f, err := newFilter(n, pr.e)
+				if err != nil {
+					t.Fatal(err)
+				}
+				start := time.Now()
+				for _, user := range usernames {
+					f.Insert(user)
+				}
+				insert += time.Since(start).Nanoseconds()
+				start = time.Now()
+				for _, user := range usernames {
+					if ok := f.Lookup(user); !ok {
+						t.Fatal("element should be in")
+					}
+				}
+				lookup += time.Since(start).Nanoseconds()
+			}
+			results[k] = append(results[k], fmt.Sprint(insert/arithmeticMean/int64(n)), fmt.Sprint(lookup/arithmeticMean/int64(n)))
+		}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/BBF_Throughput_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"error", "insert", "lookup", "insert_blocked", "lookup_blocked", "insert_blocked_masks", "lookup_blocked_masks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+func TestFPRateBlocked(t *testing.T) {
+	usernames, err := utils.ReadDatasetFromCsvAndFixLengthTo150k()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint(len(usernames))
+	lookupDataset, _ := utils.CreateDataset()
+	results := make([][]string, len(proofs))
+	for k, pr := range proofs {
+		results[k] = []string{fmt.Sprint(pr.e)}
+		for _, newFilter := range blockedVariants() {
+			f, err := newFilter(n, pr.e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, user := range usernames {
+				f.Insert(user)
+			}
+			falsePositives := 0
+			for _, elem := range lookupDataset {
+				if ok := f.Lookup(elem); ok {
+					falsePositives++
+				}
+			}
+			results[k] = append(results[k], fmt.Sprint(float64(falsePositives)/float64(len(lookupDataset))))
+		}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/BBF_FP_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"error", "fp", "fp_blocked", "fp_blocked_masks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+// blockedVariants returns the constructors of the classic BF and of the BBF without and with word masks, in this order
+func blockedVariants() []func(n uint, e float64) (filter.Filter, error) {
+	return []func(n uint, e float64) (filter.Filter, error){
+		func(n uint, e float64) (filter.Filter, error) { return NewWithOptions(n, e) },
+		func(n uint, e float64) (filter.Filter, error) { return NewBlocked(n, e) },
+		func(n uint, e float64) (filter.Filter, error) { return NewBlocked(n, e, WithWordMasks()) },
+	}
+}
@@ -1,8 +1,19 @@
 package bloomFilter
 
 import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/filter/conformance"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -113,3 +124,824 @@ func TestInsertAndLookupWithHighLoadFactor(t *testing.T) {
 		t.Errorf("Error: Expected false positives are %d ± %d and current false positives are %d", expectedFalsePositives, rangeFalsePositives, falsePositives)
 	}
 }
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		f := NewFromSizeAndError(n, e)
+		return &f
+	})
+}
+
+func TestConcurrentConformance(t *testing.T) {
+	conformance.RunConcurrent(t, func(n uint, e float64) filter.Filter {
+		f := NewFromSizeAndError(n, e)
+		c := NewConcurrent(&f)
+		c.SetParallelism(4)
+		return c
+	})
+}
+
+func TestConcurrentMarshalWhileInserting(t *testing.T) {
+	f := NewFromSizeAndError(10000, 0.01)
+	c := NewConcurrent(&f)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10000; i++ {
+			c.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		if _, err := container.Write(&buf, c); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := container.Read(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+	for i := 0; i < 10000; i++ {
+		if ok := c.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+}
+
+func TestMarshalAndUnmarshalBinary(t *testing.T) {
+	b := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		b.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BloomFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.n != b.n || decoded.m != b.m || decoded.k != b.k || decoded.e != b.e {
+		t.Errorf("Expected parameters (%d, %d, %d, %f), Current parameters (%d, %d, %d, %f)", b.n, b.m, b.k, b.e, decoded.n, decoded.m, decoded.k, decoded.e)
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if b.Lookup(elem) != decoded.Lookup(elem) {
+			t.Errorf("Lookup of %s differs after decoding", elem)
+		}
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != ErrInvalidEncoding {
+		t.Errorf("Expected error %v, Current error %v", ErrInvalidEncoding, err)
+	}
+}
+
+func TestWriteToAndReadFrom(t *testing.T) {
+	b := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		b.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	var buf bytes.Buffer
+	written, err := b.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BloomFilter
+	read, err := decoded.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != read {
+		t.Errorf("Expected %d bytes read, Current %d bytes read", written, read)
+	}
+	for i := 0; i < 1000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if ok := decoded.Lookup(elem); !ok {
+			t.Errorf("%s should be in.", elem)
+		}
+	}
+}
+
+func TestWriteAndReadWithEveryHasher(t *testing.T) {
+	for _, h := range []hasher.Hasher{hasher.Murmur3{}, hasher.XXHash{}, hasher.FNV1a{}, hasher.NewSipHash([16]byte{1, 2, 3, 4})} {
+		f, err := NewWithOptions(1000, 0.01, WithHasher(h), WithSeed(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 1000; i++ {
+			f.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		var buf bytes.Buffer
+		if _, err := container.Write(&buf, f); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := container.Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if algorithm := stored.Descriptor().Hash; algorithm != container.HashAlgorithm(hasher.AlgorithmOf(h)) {
+			t.Errorf("Expected hash algorithm %v, Current hash algorithm %v", hasher.AlgorithmOf(h), algorithm)
+		}
+		decoded := stored.(*BloomFilter)
+		if decoded.hasher != h {
+			t.Errorf("Expected hasher %v, Current hasher %v", h, decoded.hasher)
+		}
+		for i := 0; i < 2000; i++ {
+			elem := []byte(fmt.Sprintf("%d", i))
+			if f.Lookup(elem) != decoded.Lookup(elem) {
+				t.Errorf("%v: lookup of %s differs after decoding", hasher.AlgorithmOf(h), elem)
+			}
+		}
+	}
+}
+
+func TestOpenMmap(t *testing.T) {
+	b := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		b.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	path := filepath.Join(t.TempDir(), "bf")
+	if err := container.Save(path, &b); err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := OpenMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+	if mapped.Descriptor() != b.Descriptor() {
+		t.Errorf("Expected descriptor %+v, Current descriptor %+v", b.Descriptor(), mapped.Descriptor())
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if b.Lookup(elem) != mapped.Lookup(elem) {
+			t.Errorf("Lookup of %s differs in the mapped filter", elem)
+		}
+	}
+	if ok := mapped.Insert([]byte("A")); ok {
+		t.Errorf("A mapped filter should NOT accept inserts")
+	}
+}
+
+func TestDecodedFiltersAreEqual(t *testing.T) {
+	// m is not a multiple of 64 for n=100, so the decoded bit arrays span more bits than the original one
+	for _, n := range []uint{100, 1000} {
+		b := NewFromSizeAndError(n, 0.01)
+		for i := uint(0); i < n; i++ {
+			b.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		data, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded BloomFilter
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if !b.Equal(&decoded) || !decoded.Equal(&b) {
+			t.Errorf("n=%d, m=%d: A decoded filter should be equal to the original one", n, b.m)
+		}
+		path := filepath.Join(t.TempDir(), "bf")
+		if err := container.Save(path, &b); err != nil {
+			t.Fatal(err)
+		}
+		mapped, err := OpenMmap(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !b.Equal(&mapped.filter) || !mapped.filter.Equal(&decoded) {
+			t.Errorf("n=%d, m=%d: A mapped filter should be equal to the original one", n, b.m)
+		}
+		decoded.Insert([]byte("A"))
+		if b.Equal(&decoded) || mapped.filter.Equal(&decoded) {
+			t.Errorf("n=%d, m=%d: Filters with different bits should NOT be equal", n, b.m)
+		}
+		mapped.Close()
+	}
+}
+
+func TestOpenMmapDetectsCorruption(t *testing.T) {
+	b := NewFromSizeAndError(1000, 0.01)
+	var buf bytes.Buffer
+	if _, err := container.Write(&buf, &b); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	data[len(data)-1] ^= 1
+	path := filepath.Join(t.TempDir(), "bf")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMmap(path); err != container.ErrChecksumMismatch {
+		t.Errorf("Expected error %v, Current error %v", container.ErrChecksumMismatch, err)
+	}
+}
+
+// customHasher is a Hasher other than the built-in ones
+type customHasher struct {
+	hasher.FNV1a
+}
+
+func TestNewWithOptionsRejectsInvalidParameters(t *testing.T) {
+	cases := []struct {
+		n    uint
+		e    float64
+		opts []Option
+	}{
+		{0, 0.01, nil},
+		{1000, 0, nil},
+		{1000, 1, nil},
+		{1000, -0.5, nil},
+		{1000, math.NaN(), nil},
+		{1000, 0.01, []Option{WithHasher(nil)}},
+	}
+	for _, c := range cases {
+		if _, err := NewWithOptions(c.n, c.e, c.opts...); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("n = %d and e = %v should return an invalid parameter error, got %v", c.n, c.e, err)
+		}
+	}
+	for _, c := range [][2]uint{{0, 3}, {1000, 0}} {
+		if _, err := NewWithSize(c[0], c[1]); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("m = %d and k = %d should return an invalid parameter error, got %v", c[0], c[1], err)
+		}
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	size := uint(10000)
+	seeded, err := NewWithOptions(size, 0.01, WithSeed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := NewWithOptions(size, 0.01, WithHasher(customHasher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewFromSizeAndError(size, 0.01)
+	for i := uint(0); i < size; i++ {
+		element := []byte(fmt.Sprintf("%d", i))
+		b.Insert(element)
+		seeded.Insert(element)
+		custom.Insert(element)
+	}
+	for i := uint(0); i < size; i++ {
+		element := []byte(fmt.Sprintf("%d", i))
+		if !seeded.Lookup(element) || !custom.Lookup(element) {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+	if b.bits.Equal(seeded.bits) {
+		t.Errorf("A different seed should set different bits")
+	}
+	if b.bits.Equal(custom.bits) {
+		t.Errorf("A different hasher should set different bits")
+	}
+	if _, err := custom.MarshalBinary(); !errors.Is(err, ErrUnsupportedHasher) {
+		t.Errorf("Expected %v encoding a BF with a custom hasher, got %v", ErrUnsupportedHasher, err)
+	}
+	data, err := seeded.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BloomFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint(0); i < size; i++ {
+		if !decoded.Lookup([]byte(fmt.Sprintf("%d", i))) {
+			t.Errorf("%d should be in the decoded BF.", i)
+		}
+	}
+}
+
+func TestInsertAndLookupWithDoubleHashing(t *testing.T) {
+	for _, e := range []float64{0.03, 0.001, 0.0001} {
+		size := uint(200000)
+		b, err := NewWithOptions(size, e, WithDoubleHashing())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := uint(0); i < size; i++ {
+			b.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		for i := uint(0); i < size; i++ {
+			if ok := b.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+				t.Errorf("%d should be in.", i)
+			}
+		}
+		elementsToTest := 1000000
+		falsePositives := 0
+		for i := uint(0); i < uint(elementsToTest); i++ {
+			if ok := b.Lookup([]byte(fmt.Sprintf("%d", i+size))); ok {
+				falsePositives++
+			}
+		}
+		expectedFalsePositives := int(float64(elementsToTest) * e)
+		rangeFalsePositives := int(math.Ceil(float64(expectedFalsePositives)*errorRangeFalsePositives) + 1)
+		if falsePositives-expectedFalsePositives > rangeFalsePositives {
+			t.Errorf("Error (e = %v): Expected false positives are %d ± %d and current false positives are %d", e, expectedFalsePositives, rangeFalsePositives, falsePositives)
+		}
+	}
+}
+
+func TestDoubleHashingPositions(t *testing.T) {
+	b, err := NewWithSize(1000003, 7, WithDoubleHashing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	element := []byte("element")
+	h1, h2 := b.hasher.Sum128(element, b.seed)
+	p := b.positions(element)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		expected := uint((h1%uint64(b.m) + i*(h2%uint64(b.m)) + (i*i*i-i)/6) % uint64(b.m))
+		if pos := p.Next(); pos != expected {
+			t.Errorf("Position %d: expected %d, Current %d", i, expected, pos)
+		}
+	}
+}
+
+func TestInsertAndLookupDoNotAllocate(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithDoubleHashing()}} {
+		b, err := NewWithOptions(1000, 0.0001, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		element := []byte("element")
+		if allocs := testing.AllocsPerRun(100, func() { b.Insert(element) }); allocs != 0 {
+			t.Errorf("Insert (double hashing %t) should not allocate, Current allocations %v", b.doubleHashing, allocs)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { b.Lookup(element) }); allocs != 0 {
+			t.Errorf("Lookup (double hashing %t) should not allocate, Current allocations %v", b.doubleHashing, allocs)
+		}
+	}
+}
+
+func TestMarshalAndUnmarshalBinaryWithDoubleHashing(t *testing.T) {
+	b, err := NewWithOptions(1000, 0.01, WithDoubleHashing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		b.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BloomFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.doubleHashing {
+		t.Errorf("Decoded BF should use double hashing")
+	}
+	if decoded.Descriptor() != b.Descriptor() {
+		t.Errorf("Expected descriptor %v, Current descriptor %v", b.Descriptor(), decoded.Descriptor())
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if b.Lookup(elem) != decoded.Lookup(elem) {
+			t.Errorf("Lookup of %s differs after decoding", elem)
+		}
+	}
+}
+
+func TestAtomicConformance(t *testing.T) {
+	conformance.RunConcurrent(t, func(n uint, e float64) filter.Filter {
+		f, err := NewAtomic(n, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.SetParallelism(4)
+		return f
+	})
+}
+
+func TestAtomicIsInterchangeableWithBloomFilter(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithDoubleHashing(), WithSeed(5)}} {
+		b, err := NewWithOptions(10000, 0.001, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := NewAtomic(10000, 0.001, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10000; i++ {
+			b.Insert([]byte(fmt.Sprintf("%d", i)))
+			a.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		bData, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		aData, err := a.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(aData, bData) {
+			t.Fatalf("Atomic BF and BF with the same elements should have the same encoding")
+		}
+		var decodedA AtomicBloomFilter
+		if err := decodedA.UnmarshalBinary(bData); err != nil {
+			t.Fatal(err)
+		}
+		var decodedB BloomFilter
+		if err := decodedB.UnmarshalBinary(aData); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 20000; i++ {
+			elem := []byte(fmt.Sprintf("%d", i))
+			if b.Lookup(elem) != decodedA.Lookup(elem) || b.Lookup(elem) != decodedB.Lookup(elem) {
+				t.Errorf("Lookup of %s differs after decoding", elem)
+			}
+		}
+	}
+}
+
+func TestNewAtomicFrom(t *testing.T) {
+	b := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		b.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	a := NewAtomicFrom(&b)
+	for i := 0; i < 1000; i++ {
+		if ok := a.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+	if _, err := NewAtomic(0, 0.01); !errors.Is(err, filter.ErrInvalidParameter) {
+		t.Errorf("n = 0 should return an invalid parameter error, got %v", err)
+	}
+}
+
+func TestScalableConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		// Start small so the suite exercises the growth of the chain
+		s, err := NewScalable(n/10, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestScalableKeepsErrorBelowTarget(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithGrowth(4), WithTightening(0.5)}} {
+		s, err := NewScalable(1000, 0.01, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100000; i++ {
+			s.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		if len(s.filters) < 3 {
+			t.Errorf("Expected the chain to grow past 2 filters, got %d", len(s.filters))
+		}
+		if s.Error() >= 0.01 {
+			t.Errorf("Expected compound error below 0.01, got %v", s.Error())
+		}
+		falsePositives := 0
+		for i := 100000; i < 200000; i++ {
+			if s.Lookup([]byte(fmt.Sprintf("%d", i))) {
+				falsePositives++
+			}
+		}
+		if rate := float64(falsePositives) / 100000; rate > 0.01 {
+			t.Errorf("Expected false positive rate below 0.01, got %v", rate)
+		}
+	}
+}
+
+func TestScalableGrowsGeometrically(t *testing.T) {
+	s, err := NewScalable(100, 0.01, WithGrowth(3), WithTightening(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100+300+900+1; i++ {
+		s.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	expected := []struct {
+		n uint
+		e float64
+	}{{100, 0.005}, {300, 0.0025}, {900, 0.00125}, {2700, 0.000625}}
+	if len(s.filters) != len(expected) {
+		t.Fatalf("Expected %d filters, got %d", len(expected), len(s.filters))
+	}
+	for i, b := range s.filters {
+		if b.n != expected[i].n || math.Abs(b.e-expected[i].e) > 1e-12 {
+			t.Errorf("Filter %d: expected n = %d and e = %v, got n = %d and e = %v", i, expected[i].n, expected[i].e, b.n, b.e)
+		}
+	}
+	if s.count != 1 {
+		t.Errorf("Expected 1 element in the last filter, got %d", s.count)
+	}
+}
+
+func TestNewScalableRejectsInvalidParameters(t *testing.T) {
+	cases := []struct {
+		n    uint
+		e    float64
+		opts []Option
+	}{
+		{0, 0.01, nil},
+		{1000, 1, nil},
+		{1000, 0.01, []Option{WithGrowth(0)}},
+		{1000, 0.01, []Option{WithTightening(0)}},
+		{1000, 0.01, []Option{WithTightening(1)}},
+	}
+	for _, c := range cases {
+		if _, err := NewScalable(c.n, c.e, c.opts...); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("n = %d and e = %v should return an invalid parameter error, got %v", c.n, c.e, err)
+		}
+	}
+}
+
+func TestScalableMarshalAndUnmarshalBinary(t *testing.T) {
+	s, err := NewScalable(100, 0.01, WithDoubleHashing(), WithHasher(hasher.XXHash{}), WithSeed(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		s.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ScalableBloomFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Descriptor() != s.Descriptor() || len(decoded.filters) != len(s.filters) || decoded.count != s.count {
+		t.Fatalf("Expected descriptor %+v, Current descriptor %+v", s.Descriptor(), decoded.Descriptor())
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if s.Lookup(elem) != decoded.Lookup(elem) {
+			t.Errorf("Lookup of %s differs after decoding", elem)
+		}
+	}
+	// The decoded SBF keeps growing as the original one
+	for i := 1000; i < 3000; i++ {
+		s.Insert([]byte(fmt.Sprintf("%d", i)))
+		decoded.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	if len(decoded.filters) != len(s.filters) || decoded.filters[len(decoded.filters)-1].seed != 7 {
+		t.Errorf("Expected %d filters with seed 7 after growing, got %d", len(s.filters), len(decoded.filters))
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != ErrInvalidEncoding {
+		t.Errorf("Expected error %v, Current error %v", ErrInvalidEncoding, err)
+	}
+	// A chain whose second filter does not follow the growth of the first one is rejected
+	corrupted := append([]byte(nil), data...)
+	corrupted[3*8] = 5
+	if err := decoded.UnmarshalBinary(corrupted); err != ErrInvalidEncoding {
+		t.Errorf("Expected error %v, Current error %v", ErrInvalidEncoding, err)
+	}
+}
+
+func TestScalableWriteToAndReadFrom(t *testing.T) {
+	s, err := NewScalable(100, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		s.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	var buf bytes.Buffer
+	written, err := s.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString("trailing data")
+	var decoded ScalableBloomFilter
+	read, err := decoded.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != read {
+		t.Errorf("Expected %d bytes read, Current %d bytes read", written, read)
+	}
+	for i := 0; i < 1000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if ok := decoded.Lookup(elem); !ok {
+			t.Errorf("%s should be in.", elem)
+		}
+	}
+	s, _ = NewScalable(100, 0.01, WithHasher(customHasher{}))
+	if _, err := s.MarshalBinary(); err != ErrUnsupportedHasher {
+		t.Errorf("Expected error %v, Current error %v", ErrUnsupportedHasher, err)
+	}
+}
+
+func TestBlockedConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		b, err := NewBlocked(n, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	})
+}
+
+func TestBlockedWithWordMasksConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		b, err := NewBlocked(n, e, WithWordMasks(), WithHasher(hasher.XXHash{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	})
+}
+
+func TestNewBlockedRoundsToWholeBlocks(t *testing.T) {
+	b, err := NewBlockedWithSize(730, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.filter.m != 1024 || b.blocks != 2 || b.TotalSize() != 128 {
+		t.Errorf("Expected 2 blocks of 512 bits in 128 bytes, got m = %d, %d blocks and %d bytes", b.filter.m, b.blocks, b.TotalSize())
+	}
+	if _, err := NewBlocked(0, 0.01); !errors.Is(err, filter.ErrInvalidParameter) {
+		t.Errorf("n = 0 should return an invalid parameter error, got %v", err)
+	}
+	if _, err := NewBlockedWithSize(1024, 0); !errors.Is(err, filter.ErrInvalidParameter) {
+		t.Errorf("k = 0 should return an invalid parameter error, got %v", err)
+	}
+}
+
+func TestBlockedSetsBitsOfASingleBlock(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithWordMasks()}} {
+		b, err := NewBlocked(10000, 0.01, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Insert([]byte("Hello World"))
+		set, blocks := 0, map[int]bool{}
+		for i, w := range b.words {
+			if w != 0 {
+				blocks[i/blockWords] = true
+				set += bits.OnesCount64(w)
+			}
+		}
+		if len(blocks) != 1 || set == 0 || set > int(b.filter.k) {
+			t.Errorf("Expected at most %d bits set in a single block, got %d bits in %d blocks", b.filter.k, set, len(blocks))
+		}
+	}
+}
+
+func TestUnionAndIntersect(t *testing.T) {
+	a, b := NewFromSizeAndError(2000, 0.001), NewFromSizeAndError(2000, 0.001)
+	for i := 0; i < 1000; i++ {
+		a.Insert([]byte(fmt.Sprintf("%d", i)))
+		b.Insert([]byte(fmt.Sprintf("%d", i+500)))
+	}
+	union, intersection := NewFromSizeAndError(2000, 0.001), NewFromSizeAndError(2000, 0.001)
+	for _, f := range []*BloomFilter{&union, &intersection} {
+		if err := f.Union(&a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := union.Union(&b); err != nil {
+		t.Fatal(err)
+	}
+	if err := intersection.Intersect(&b); err != nil {
+		t.Fatal(err)
+	}
+	falsePositives := 0
+	for i := 0; i < 1500; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if !union.Lookup(elem) {
+			t.Errorf("%s should be in the union.", elem)
+		}
+		if i >= 500 && i < 1000 && !intersection.Lookup(elem) {
+			t.Errorf("%s should be in the intersection.", elem)
+		}
+		if (i < 500 || i >= 1000) && intersection.Lookup(elem) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 10 {
+		t.Errorf("Expected around 1 false positive in the intersection, got %d", falsePositives)
+	}
+	all := NewFromSizeAndError(2000, 0.001)
+	for i := 0; i < 1500; i++ {
+		all.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	if !union.Equal(&all) || union.Equal(&a) {
+		t.Errorf("The union should be equal to the BF holding both sets and differ from the first one")
+	}
+}
+
+func TestCardinalityEstimators(t *testing.T) {
+	a, b := NewFromSizeAndError(20000, 0.01), NewFromSizeAndError(20000, 0.01)
+	for i := 0; i < 10000; i++ {
+		a.Insert([]byte(fmt.Sprintf("%d", i)))
+		b.Insert([]byte(fmt.Sprintf("%d", i+5000)))
+	}
+	union, err := a.EstimateUnionCardinality(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intersection, err := a.EstimateIntersectionCardinality(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jaccard, err := a.EstimateJaccard(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimates := []struct {
+		name              string
+		value, exact, tol float64
+	}{
+		{"cardinality", a.EstimateCardinality(), 10000, 200},
+		{"union", union, 15000, 300},
+		{"intersection", intersection, 5000, 300},
+		{"jaccard", jaccard, 1.0 / 3, 0.03},
+	}
+	for _, e := range estimates {
+		if math.Abs(e.value-e.exact) > e.tol {
+			t.Errorf("Expected %s around %v, got %v", e.name, e.exact, e.value)
+		}
+	}
+	empty := NewFromSizeAndError(20000, 0.01)
+	if jaccard, err := empty.EstimateJaccard(&empty); err != nil || jaccard != 0 {
+		t.Errorf("Expected Jaccard similarity 0 of empty filters, got %v and %v", jaccard, err)
+	}
+}
+
+func TestSetAlgebraRejectsIncompatibleFilters(t *testing.T) {
+	a := NewFromSizeAndError(1000, 0.01)
+	others := map[string]*BloomFilter{}
+	for parameter, opts := range map[string][]Option{"seed": {WithSeed(1)}, "doubleHashing": {WithDoubleHashing()}, "hasher": {WithHasher(hasher.XXHash{})}} {
+		others[parameter], _ = NewWithOptions(1000, 0.01, opts...)
+	}
+	others["m"], _ = NewWithOptions(2000, 0.01)
+	others["k"], _ = NewWithSize(a.m, a.k+1)
+	for parameter, other := range others {
+		var incompatible *filter.IncompatibleError
+		if err := a.Union(other); !errors.As(err, &incompatible) || incompatible.Parameter != parameter || !errors.Is(err, filter.ErrIncompatible) {
+			t.Errorf("Union with a different %s should return an incompatible error, got %v", parameter, err)
+		}
+		if err := a.Intersect(other); !errors.Is(err, filter.ErrIncompatible) {
+			t.Errorf("Intersect with a different %s should return an incompatible error, got %v", parameter, err)
+		}
+		if _, err := a.EstimateJaccard(other); !errors.Is(err, filter.ErrIncompatible) {
+			t.Errorf("EstimateJaccard with a different %s should return an incompatible error, got %v", parameter, err)
+		}
+		if a.Equal(other) {
+			t.Errorf("Filters with a different %s should not be equal", parameter)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	b := NewFromSizeAndError(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		b.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	stats := b.Stats()
+	fill := float64(b.bits.Count()) / float64(b.m)
+	if stats.LoadFactor != fill || stats.FalsePositiveRate != math.Pow(fill, float64(b.k)) || !stats.CountIsEstimate {
+		t.Errorf("Expected load factor %v and false positive rate %v, got %+v", fill, math.Pow(fill, float64(b.k)), stats)
+	}
+	if math.Abs(stats.FalsePositiveRate-0.01) > 0.002 || math.Abs(float64(stats.Count)-10000) > 200 {
+		t.Errorf("Expected around 10000 elements and a false positive rate around 0.01 when full, got %+v", stats)
+	}
+	if a := NewAtomicFrom(&b); a.Stats() != stats {
+		t.Errorf("Expected the Atomic BF to report %+v, got %+v", stats, a.Stats())
+	}
+	full, _ := NewWithSize(64, 1)
+	for i := 0; i < 10000; i++ {
+		full.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	if stats := full.Stats(); stats.Count == 0 || math.IsInf(float64(stats.Count), 0) || stats.FalsePositiveRate != 1 {
+		t.Errorf("Expected a finite count and a false positive rate of 1 when every bit is set, got %+v", stats)
+	}
+}
+
+func TestDecodingRejectsUntrustedSizes(t *testing.T) {
+	b := NewFromSizeAndError(1000, 0.01)
+	valid, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// m and the number of words of the bit array, which must neither overflow nor be allocated before reading them
+	cases := [][2]uint64{
+		{math.MaxUint64, 0},
+		{1 << 62, 1 << 56},
+	}
+	for _, c := range cases {
+		data := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint64(data[2*utils.WordSize:], c[0])
+		binary.LittleEndian.PutUint64(data[10*utils.WordSize:], c[1])
+		var decoded BloomFilter
+		if err := decoded.UnmarshalBinary(data); err != ErrInvalidEncoding {
+			t.Errorf("m=%d: Expected error %v, Current error %v", c[0], ErrInvalidEncoding, err)
+		}
+		if _, err := decoded.ReadFrom(bytes.NewReader(data)); err == nil {
+			t.Errorf("m=%d: Reading a corrupted encoding should fail", c[0])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"sync"
+)
+
+var (
+	_ filter.BatchFilter = (*ConcurrentBloomFilter)(nil)
+	_ container.Storable = (*ConcurrentBloomFilter)(nil)
+)
+
+// ConcurrentBloomFilter is a Bloom Filter safe for concurrent use by multiple goroutines.
+// Lookups proceed in parallel, while inserts hold the filter exclusively
+type ConcurrentBloomFilter struct {
+	mu     sync.RWMutex
+	filter *BloomFilter
+	// parallelism is the number of goroutines LookupBatch fans out to
+	parallelism int
+}
+
+// NewConcurrent wraps b so it can be shared between goroutines. b must not be used directly afterwards
+func NewConcurrent(b *BloomFilter) *ConcurrentBloomFilter {
+	return &ConcurrentBloomFilter{filter: b}
+}
+
+// SetParallelism sets the number of goroutines LookupBatch splits its elements between. By default it does not fan out.
+// It must be called before sharing the filter
+func (c *ConcurrentBloomFilter) SetParallelism(goroutines int) {
+	c.parallelism = goroutines
+}
+
+// Insert inserts element into BF. Always returns true, as a BF cannot fail on insert. Computational time: O(k)
+func (c *ConcurrentBloomFilter) Insert(element []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.Insert(element)
+}
+
+// Lookup returns true if element may belong to the BF and false if element does not belong to the BF. Computational time: O(k)
+func (c *ConcurrentBloomFilter) Lookup(element []byte) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.Lookup(element)
+}
+
+// InsertBatch inserts elements into BF, holding the filter exclusively once for the whole batch. Always returns nil
+func (c *ConcurrentBloomFilter) InsertBatch(elements [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.InsertBatch(elements)
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in BF, splitting the elements between the goroutines set by SetParallelism
+func (c *ConcurrentBloomFilter) LookupBatch(elements [][]byte, results []bool) {
+	utils.ParallelChunks(len(elements), c.parallelism, func(start int, end int) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		c.filter.LookupBatch(elements[start:end], results[start:end])
+	})
+}
+
+// TotalSize returns an estimation (in bytes) of the size of the array that represents BF.
+func (c *ConcurrentBloomFilter) TotalSize() uint {
+	return c.filter.TotalSize()
+}
+
+// Descriptor returns the description of BF written in the header of a container
+func (c *ConcurrentBloomFilter) Descriptor() container.Descriptor {
+	return c.filter.Descriptor()
+}
+
+// MarshalBinary encodes a snapshot of BF, with the same encoding as BloomFilter.MarshalBinary
+func (c *ConcurrentBloomFilter) MarshalBinary() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.MarshalBinary()
+}
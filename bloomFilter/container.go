@@ -0,0 +1,38 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/hasher"
+)
+
+var _ container.Storable = (*BloomFilter)(nil)
+
+func init() {
+	container.Register(container.KindBloom, func(payload []byte) (container.Storable, error) {
+		b := new(BloomFilter)
+		if err := b.UnmarshalBinary(payload); err != nil {
+			return nil, err
+		}
+		return b, nil
+	})
+	container.Register(container.KindScalableBloom, func(payload []byte) (container.Storable, error) {
+		s := new(ScalableBloomFilter)
+		if err := s.UnmarshalBinary(payload); err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+}
+
+// Descriptor returns the description of BF written in the header of a container
+func (b *BloomFilter) Descriptor() container.Descriptor {
+	return container.Descriptor{
+		Kind:   container.KindBloom,
+		Hash:   container.HashAlgorithm(hasher.AlgorithmOf(b.hasher)),
+		Seed:   uint64(b.seed),
+		N:      uint64(b.n),
+		M:      uint64(b.m),
+		E:      b.e,
+		Params: [2]uint64{uint64(b.k), boolToWord(b.doubleHashing)},
+	}
+}
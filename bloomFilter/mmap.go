@@ -0,0 +1,94 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"errors"
+
+	"github.com/bits-and-blooms/bitset"
+)
+
+var _ filter.Filter = (*MmapBloomFilter)(nil)
+
+// ErrWrongKind is returned when opening a container that does not store a BF
+var ErrWrongKind = errors.New("bloomFilter: container does not store a Bloom Filter")
+
+// MmapBloomFilter is a read-only Bloom Filter that answers lookups directly against a memory-mapped container
+type MmapBloomFilter struct {
+	filter BloomFilter
+	data   []byte
+}
+
+// OpenMmap maps the container at path, written with container.Save, without copying its bit array into the heap.
+// The mapping is shared, so processes opening the same file share its pages. Close must be called to release it
+func OpenMmap(path string) (*MmapBloomFilter, error) {
+	data, err := utils.Mmap(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := mapBF(data)
+	if err != nil {
+		utils.Munmap(data)
+		return nil, err
+	}
+	return &MmapBloomFilter{filter: b, data: data}, nil
+}
+
+// Insert always returns false, as a memory-mapped BF is read-only
+func (b *MmapBloomFilter) Insert(element []byte) bool {
+	return false
+}
+
+// Lookup returns true if element may belong to the BF and false if element does not belong to the BF. Computational time: O(k)
+func (b *MmapBloomFilter) Lookup(element []byte) bool {
+	return b.filter.Lookup(element)
+}
+
+// TotalSize returns an estimation (in bytes) of the size of the array that represents BF.
+func (b *MmapBloomFilter) TotalSize() uint {
+	return b.filter.TotalSize()
+}
+
+// Descriptor returns the description of BF written in the header of its container
+func (b *MmapBloomFilter) Descriptor() container.Descriptor {
+	return b.filter.Descriptor()
+}
+
+// Close unmaps the BF. It must not be used afterwards
+func (b *MmapBloomFilter) Close() error {
+	data := b.data
+	b.data = nil
+	b.filter.bits = nil
+	return utils.Munmap(data)
+}
+
+func mapBF(data []byte) (BloomFilter, error) {
+	h, payload, err := container.Split(data)
+	if err != nil {
+		return BloomFilter{}, err
+	}
+	if h.Kind != container.KindBloom {
+		return BloomFilter{}, ErrWrongKind
+	}
+	header, words, err := utils.ReadWords(payload, headerWords)
+	if err != nil {
+		return BloomFilter{}, ErrInvalidEncoding
+	}
+	b, err := decodeHeader(header)
+	if err != nil {
+		return BloomFilter{}, err
+	}
+	if uint64(len(words)) != header[10]*utils.WordSize {
+		return BloomFilter{}, ErrInvalidEncoding
+	}
+	bits, err := utils.AliasWords(words)
+	if err != nil {
+		return BloomFilter{}, err
+	}
+	b.bits = bitset.From(bits)
+	if b.Descriptor() != h.Descriptor {
+		return BloomFilter{}, container.ErrDescriptorMismatch
+	}
+	return b, nil
+}
@@ -0,0 +1,103 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+)
+
+// Option configures a BF created with NewWithOptions or NewWithSize
+type Option func(*config) error
+
+type config struct {
+	seed          uint32
+	hasher        hasher.Hasher
+	doubleHashing bool
+	// growth and tightening are only used by ScalableBloomFilter
+	growth     uint
+	tightening float64
+	// wordMasks is only used by BlockedBloomFilter
+	wordMasks bool
+}
+
+func newConfig(opts []Option) (config, error) {
+	cfg := config{
+		hasher:     hasher.Murmur3{},
+		growth:     defaultGrowth,
+		tightening: defaultTightening,
+	}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// WithSeed sets the seed of the first hash function. The i-th hash function uses seed+i. The default seed is 0
+func WithSeed(seed uint32) Option {
+	return func(c *config) error {
+		c.seed = seed
+		return nil
+	}
+}
+
+// WithHasher sets the hash function used to compute the k positions. The default is hasher.Murmur3. Only the built-in hashers can be serialized
+func WithHasher(h hasher.Hasher) Option {
+	return func(c *config) error {
+		if h == nil {
+			return parameterError("hasher", h, "must not be nil")
+		}
+		c.hasher = h
+		return nil
+	}
+}
+
+// WithDoubleHashing derives the k positions from a single 128-bit hash using enhanced double hashing
+// (Kirsch and Mitzenmacher, improved by Dillinger and Manolios) instead of computing k hashes with seeds seed..seed+k-1
+func WithDoubleHashing() Option {
+	return func(c *config) error {
+		c.doubleHashing = true
+		return nil
+	}
+}
+
+// WithGrowth sets how many times larger the capacity of every filter of a ScalableBloomFilter is than the previous one. The default is 2
+func WithGrowth(growth uint) Option {
+	return func(c *config) error {
+		if growth == 0 {
+			return parameterError("growth", growth, "growth factor must be greater than 0")
+		}
+		c.growth = growth
+		return nil
+	}
+}
+
+// WithTightening sets the ratio between the false positive errors of every filter of a ScalableBloomFilter and the previous one.
+// Lower ratios give fewer, larger filters. The default is 0.8
+func WithTightening(ratio float64) Option {
+	return func(c *config) error {
+		if !(ratio > 0 && ratio < 1) {
+			return parameterError("tightening", ratio, "tightening ratio must be between 0 and 1 (exclusive)")
+		}
+		c.tightening = ratio
+		return nil
+	}
+}
+
+// WithWordMasks makes a BlockedBloomFilter set the k bits of an element in consecutive words of its block, so the bits
+// of an element are tested by comparing 8 word masks, which compilers can turn into SIMD instructions
+func WithWordMasks() Option {
+	return func(c *config) error {
+		c.wordMasks = true
+		return nil
+	}
+}
+
+func parameterError(parameter string, value interface{}, reason string) error {
+	return &filter.ParameterError{
+		Filter:    "bloomFilter",
+		Parameter: parameter,
+		Value:     value,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,264 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"encoding"
+	"io"
+	"math"
+)
+
+const (
+	defaultGrowth     = 2
+	defaultTightening = 0.8
+	// version, n, e, growth, tightening ratio, elements in the last filter and number of filters
+	scalableHeaderWords = 7
+	// maxScalableFilters bounds the number of filters of a decoded SBF, enough for any chain whose filters fit in memory
+	maxScalableFilters = 64
+)
+
+var (
+	_ filter.Filter              = (*ScalableBloomFilter)(nil)
+	_ container.Storable         = (*ScalableBloomFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*ScalableBloomFilter)(nil)
+	_ io.WriterTo                = (*ScalableBloomFilter)(nil)
+	_ io.ReaderFrom              = (*ScalableBloomFilter)(nil)
+)
+
+// ScalableBloomFilter is a Scalable Bloom Filter (Almeida et al.): a chain of BloomFilters that grows when the last one is full.
+// The i-th filter holds n*growth^i elements with e*(1-r)*r^i false positive error, r being the tightening ratio,
+// so the compound false positive error stays below e however many elements are inserted
+type ScalableBloomFilter struct {
+	n          uint
+	e          float64
+	growth     uint
+	tightening float64
+	cfg        config
+	filters    []*BloomFilter
+	// count is the number of elements inserted into the last filter
+	count uint
+}
+
+// NewScalable creates a new Scalable Bloom Filter whose first filter holds n elements, keeping its false positive error below e.
+// It is configured with opts, which also apply to every filter of the chain
+func NewScalable(n uint, e float64, opts ...Option) (*ScalableBloomFilter, error) {
+	if err := validateCapacityAndError(n, e); err != nil {
+		return nil, err
+	}
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	s := &ScalableBloomFilter{
+		n:          n,
+		e:          e,
+		growth:     cfg.growth,
+		tightening: cfg.tightening,
+		cfg:        cfg,
+	}
+	s.grow()
+	return s, nil
+}
+
+// Insert inserts element into the last filter of SBF, adding a new filter first if it is full. Always returns true. Amortized computational time: O(k)
+func (s *ScalableBloomFilter) Insert(element []byte) bool {
+	last := s.filters[len(s.filters)-1]
+	if s.count >= last.n {
+		last = s.grow()
+	}
+	last.Insert(element)
+	s.count++
+	return true
+}
+
+// Lookup returns true if element may belong to the SBF and false if element does not belong to the SBF.
+// Computational time: O(k*l), l being the number of filters
+func (s *ScalableBloomFilter) Lookup(element []byte) bool {
+	for i := len(s.filters) - 1; i >= 0; i-- {
+		if s.filters[i].Lookup(element) {
+			return true
+		}
+	}
+	return false
+}
+
+// TotalSize returns an estimation (in bytes) of the size of the arrays of all the filters of SBF.
+func (s *ScalableBloomFilter) TotalSize() uint {
+	size := uint(0)
+	for _, b := range s.filters {
+		size += b.TotalSize()
+	}
+	return size
+}
+
+// Error returns the compound false positive error of the filters of SBF when full, which is always below e
+func (s *ScalableBloomFilter) Error() float64 {
+	noFalsePositive := 1.0
+	for _, b := range s.filters {
+		noFalsePositive *= 1 - b.e
+	}
+	return 1 - noFalsePositive
+}
+
+// Descriptor returns the description of SBF written in the header of a container. M is the size of all its filters
+func (s *ScalableBloomFilter) Descriptor() container.Descriptor {
+	m := uint(0)
+	for _, b := range s.filters {
+		m += b.m
+	}
+	return container.Descriptor{
+		Kind:   container.KindScalableBloom,
+		Hash:   container.HashAlgorithm(hasher.AlgorithmOf(s.cfg.hasher)),
+		Seed:   uint64(s.cfg.seed),
+		N:      uint64(s.n),
+		M:      uint64(m),
+		E:      s.e,
+		Params: [2]uint64{uint64(s.growth), math.Float64bits(s.tightening)},
+	}
+}
+
+// MarshalBinary encodes SBF as a little-endian sequence of 64-bit words: its header followed by the encoding of every filter
+func (s *ScalableBloomFilter) MarshalBinary() ([]byte, error) {
+	data := utils.AppendWords(nil, encodingVersion, uint64(s.n), math.Float64bits(s.e), uint64(s.growth), math.Float64bits(s.tightening), uint64(s.count), uint64(len(s.filters)))
+	for _, b := range s.filters {
+		encoded, err := b.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, encoded...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a SBF previously encoded with MarshalBinary, replacing the content of s
+func (s *ScalableBloomFilter) UnmarshalBinary(data []byte) error {
+	header, data, err := utils.ReadWords(data, scalableHeaderWords)
+	if err != nil {
+		return ErrInvalidEncoding
+	}
+	if err := checkScalableHeader(header); err != nil {
+		return err
+	}
+	filters := make([]*BloomFilter, 0, header[6])
+	for i := uint64(0); i < header[6]; i++ {
+		layer, err := layerHeader(data)
+		if err != nil {
+			return err
+		}
+		length := (headerWords + layer[10]) * utils.WordSize
+		if uint64(len(data)) < length {
+			return ErrInvalidEncoding
+		}
+		b := new(BloomFilter)
+		if err := b.UnmarshalBinary(data[:length]); err != nil {
+			return err
+		}
+		filters = append(filters, b)
+		data = data[length:]
+	}
+	if len(data) != 0 {
+		return ErrInvalidEncoding
+	}
+	return s.decode(header, filters)
+}
+
+// WriteTo writes the binary encoding of SBF to w
+func (s *ScalableBloomFilter) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoding(w, s)
+}
+
+// ReadFrom reads a binary encoding of a SBF from r, replacing the content of s
+func (s *ScalableBloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	data, n, err := utils.ReadFullWords(r, nil, scalableHeaderWords)
+	if err != nil {
+		return n, err
+	}
+	header, _, _ := utils.ReadWords(data, scalableHeaderWords)
+	if err := checkScalableHeader(header); err != nil {
+		return n, err
+	}
+	filters := make([]*BloomFilter, header[6])
+	for i := range filters {
+		filters[i] = new(BloomFilter)
+		read, err := readEncoding(r, filters[i])
+		n += read
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, s.decode(header, filters)
+}
+
+// grow appends a new filter to the chain, larger and with lower error than the last one, and returns it
+func (s *ScalableBloomFilter) grow() *BloomFilter {
+	n, e := s.capacityAndError(uint(len(s.filters)))
+	m, k := OptimalSize(n, e)
+	b := newBFWithConfig(n, e, m, k, s.cfg)
+	s.filters = append(s.filters, b)
+	s.count = 0
+	return b
+}
+
+// capacityAndError returns the capacity and the false positive error of the i-th filter of the chain
+func (s *ScalableBloomFilter) capacityAndError(i uint) (uint, float64) {
+	n := s.n
+	for j := uint(0); j < i; j++ {
+		n *= s.growth
+	}
+	return n, s.e * (1 - s.tightening) * math.Pow(s.tightening, float64(i))
+}
+
+// decode replaces the content of s with the SBF described by header and filters, checking that every filter is the one the chain would create
+func (s *ScalableBloomFilter) decode(header []uint64, filters []*BloomFilter) error {
+	first := filters[0]
+	cfg := config{seed: first.seed, hasher: first.hasher, doubleHashing: first.doubleHashing, growth: uint(header[3]), tightening: math.Float64frombits(header[4])}
+	decoded := ScalableBloomFilter{
+		n:          uint(header[1]),
+		e:          math.Float64frombits(header[2]),
+		growth:     cfg.growth,
+		tightening: cfg.tightening,
+		cfg:        cfg,
+		filters:    filters,
+		count:      uint(header[5]),
+	}
+	if decoded.n == 0 || !(decoded.e > 0 && decoded.e < 1) || decoded.growth == 0 || !(decoded.tightening > 0 && decoded.tightening < 1) {
+		return ErrInvalidEncoding
+	}
+	for i, b := range filters {
+		n, e := decoded.capacityAndError(uint(i))
+		m, k := OptimalSize(n, e)
+		if b.n != n || b.e != e || b.m != m || b.k != k || b.seed != cfg.seed || !hasher.Equal(b.hasher, cfg.hasher) || b.doubleHashing != cfg.doubleHashing {
+			return ErrInvalidEncoding
+		}
+	}
+	if decoded.count > filters[len(filters)-1].n {
+		return ErrInvalidEncoding
+	}
+	*s = decoded
+	return nil
+}
+
+// checkScalableHeader checks the version and the number of filters of the header of a SBF encoding
+func checkScalableHeader(header []uint64) error {
+	if header[0] != encodingVersion {
+		return ErrUnsupportedVersion
+	}
+	if header[6] == 0 || header[6] > maxScalableFilters {
+		return ErrInvalidEncoding
+	}
+	return nil
+}
+
+// layerHeader returns the header of the encoding of the BF data starts with
+func layerHeader(data []byte) ([]uint64, error) {
+	header, _, err := utils.ReadWords(data, headerWords)
+	if err != nil {
+		return nil, ErrInvalidEncoding
+	}
+	if _, err := decodeHeader(header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
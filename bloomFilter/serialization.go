@@ -0,0 +1,151 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"encoding"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/bits-and-blooms/bitset"
+)
+
+const (
+	encodingVersion = uint64(4)
+	// version, n, m, k, e, seed, hash algorithm, the two words of its key, double hashing flag and number of words of the bit array
+	headerWords = 11
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*BloomFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*BloomFilter)(nil)
+	_ io.WriterTo                = (*BloomFilter)(nil)
+	_ io.ReaderFrom              = (*BloomFilter)(nil)
+)
+
+var (
+	// ErrInvalidEncoding is returned when decoding data that does not represent a BF
+	ErrInvalidEncoding = errors.New("bloomFilter: invalid binary encoding")
+	// ErrUnsupportedVersion is returned when decoding data encoded with an unknown version
+	ErrUnsupportedVersion = errors.New("bloomFilter: unsupported encoding version")
+	// ErrUnsupportedHasher is returned when encoding a BF whose hash function is not one of the built-in hashers
+	ErrUnsupportedHasher = errors.New("bloomFilter: only the built-in hashers can be encoded")
+)
+
+// MarshalBinary encodes BF as a little-endian sequence of 64-bit words: the header followed by the bit array
+func (b *BloomFilter) MarshalBinary() ([]byte, error) {
+	return b.encode(b.bits.Bytes())
+}
+
+// UnmarshalBinary decodes a BF previously encoded with MarshalBinary, replacing the content of b
+func (b *BloomFilter) UnmarshalBinary(data []byte) error {
+	filter, words, err := decode(data)
+	if err != nil {
+		return err
+	}
+	filter.bits = bitset.From(words).Shrink(filter.m - 1)
+	*b = filter
+	return nil
+}
+
+// WriteTo writes the binary encoding of BF to w
+func (b *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoding(w, b)
+}
+
+// ReadFrom reads a binary encoding of a BF from r, replacing the content of b
+func (b *BloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	return readEncoding(r, b)
+}
+
+// encode returns the encoding of the BF described by b with the bit array words
+func (b *BloomFilter) encode(words []uint64) ([]byte, error) {
+	algorithm, key, err := hasher.Encode(b.hasher)
+	if err != nil {
+		return nil, ErrUnsupportedHasher
+	}
+	data := make([]byte, 0, (headerWords+len(words))*utils.WordSize)
+	data = utils.AppendWords(data, encodingVersion, uint64(b.n), uint64(b.m), uint64(b.k), math.Float64bits(b.e), uint64(b.seed), uint64(algorithm), key[0], key[1], boolToWord(b.doubleHashing), uint64(len(words)))
+	return utils.AppendWords(data, words...), nil
+}
+
+// decode returns the BF described by data, without its bit array, and the words of the bit array
+func decode(data []byte) (BloomFilter, []uint64, error) {
+	header, data, err := utils.ReadWords(data, headerWords)
+	if err != nil {
+		return BloomFilter{}, nil, ErrInvalidEncoding
+	}
+	filter, err := decodeHeader(header)
+	if err != nil {
+		return BloomFilter{}, nil, err
+	}
+	words, data, err := utils.ReadWords(data, header[10])
+	if err != nil || len(data) != 0 {
+		return BloomFilter{}, nil, ErrInvalidEncoding
+	}
+	return filter, words, nil
+}
+
+// writeEncoding writes the binary encoding of m to w
+func writeEncoding(w io.Writer, m encoding.BinaryMarshaler) (int64, error) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// readEncoding reads from r exactly one encoding of a BF and decodes it with u
+func readEncoding(r io.Reader, u encoding.BinaryUnmarshaler) (int64, error) {
+	data, n, err := utils.ReadFullWords(r, nil, headerWords)
+	if err != nil {
+		return n, err
+	}
+	header, _, _ := utils.ReadWords(data, headerWords)
+	if _, err := decodeHeader(header); err != nil {
+		return n, err
+	}
+	data, read, err := utils.ReadFullWords(r, data, header[10])
+	n += read
+	if err != nil {
+		return n, err
+	}
+	return n, u.UnmarshalBinary(data)
+}
+
+// decodeHeader returns the BF described by header, without its bit array
+func decodeHeader(header []uint64) (BloomFilter, error) {
+	if header[0] != encodingVersion {
+		return BloomFilter{}, ErrUnsupportedVersion
+	}
+	m, k := uint(header[2]), uint(header[3])
+	if m == 0 || !utils.PackedArrayFits(m, 1) || k == 0 || header[5] > math.MaxUint32 || header[6] > math.MaxUint8 || header[9] > 1 || header[10] != uint64(wordsNeeded(m)) {
+		return BloomFilter{}, ErrInvalidEncoding
+	}
+	h, err := hasher.Decode(hasher.Algorithm(header[6]), [2]uint64{header[7], header[8]})
+	if err != nil {
+		return BloomFilter{}, ErrInvalidEncoding
+	}
+	return BloomFilter{
+		n:             uint(header[1]),
+		m:             m,
+		k:             k,
+		e:             math.Float64frombits(header[4]),
+		seed:          uint32(header[5]),
+		hasher:        h,
+		doubleHashing: header[9] == 1,
+	}, nil
+}
+
+func boolToWord(value bool) uint64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func wordsNeeded(m uint) int {
+	return int((m + utils.Machine64Bits - 1) / utils.Machine64Bits)
+}
@@ -0,0 +1,112 @@
+package bloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+var (
+	_ filter.StatsReporter = (*BloomFilter)(nil)
+	_ filter.StatsReporter = (*ConcurrentBloomFilter)(nil)
+	_ filter.StatsReporter = (*AtomicBloomFilter)(nil)
+	_ filter.StatsReporter = (*MmapBloomFilter)(nil)
+	_ filter.StatsReporter = (*BlockedBloomFilter)(nil)
+	_ filter.StatsReporter = (*ScalableBloomFilter)(nil)
+)
+
+// Stats returns the statistics of BF, its count estimated from its set bits. Computational time: O(m)
+func (b *BloomFilter) Stats() filter.Stats {
+	return b.stats(b.bits.Count(), uint(len(b.bits.Bytes()))*utils.WordSize)
+}
+
+// Stats returns the statistics of BF, holding the read lock while counting its set bits. Computational time: O(m)
+func (c *ConcurrentBloomFilter) Stats() filter.Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.Stats()
+}
+
+// Stats returns the statistics of BF, its count estimated from its set bits. Concurrent inserts may or may not be counted. Computational time: O(m)
+func (a *AtomicBloomFilter) Stats() filter.Stats {
+	set := uint(0)
+	for i := range a.words {
+		set += uint(bits.OnesCount64(atomic.LoadUint64(&a.words[i])))
+	}
+	return a.filter.stats(set, uint(len(a.words))*utils.WordSize)
+}
+
+// Stats returns the statistics of BF, its count estimated from its set bits. Computational time: O(m)
+func (b *MmapBloomFilter) Stats() filter.Stats {
+	return b.filter.Stats()
+}
+
+// Stats returns the statistics of BBF, its count estimated from the set bits of every block. Computational time: O(m)
+func (b *BlockedBloomFilter) Stats() filter.Stats {
+	set, count, falsePositiveRate := uint(0), 0.0, 0.0
+	k := float64(b.filter.k)
+	for start := 0; start < len(b.words); start += blockWords {
+		blockSet := 0
+		for _, w := range b.words[start : start+blockWords] {
+			blockSet += bits.OnesCount64(w)
+		}
+		set += uint(blockSet)
+		// An element is a false positive when all its bits fall on set bits of its block
+		fill := math.Min(float64(blockSet), blockBits-1) / blockBits
+		count += -blockBits / k * math.Log(1-fill)
+		falsePositiveRate += math.Pow(fill, k)
+	}
+	return filter.Stats{
+		Count:             uint(math.Round(count)),
+		CountIsEstimate:   true,
+		LoadFactor:        float64(set) / float64(b.filter.m),
+		Capacity:          b.filter.n,
+		TargetError:       b.filter.e,
+		FalsePositiveRate: falsePositiveRate / float64(b.blocks),
+		MemoryBytes:       b.TotalSize(),
+	}
+}
+
+// Stats returns the statistics of SBF: its count estimated from the set bits of all its filters, its capacity the one of all its filters
+// before growing again and its false positive rate the compound one of all its filters. Computational time: O(m)
+func (s *ScalableBloomFilter) Stats() filter.Stats {
+	stats := filter.Stats{CountIsEstimate: true, TargetError: s.e}
+	set, m := 0.0, 0.0
+	noFalsePositive := 1.0
+	for _, b := range s.filters {
+		layer := b.Stats()
+		stats.Count += layer.Count
+		stats.Capacity += layer.Capacity
+		stats.MemoryBytes += layer.MemoryBytes
+		noFalsePositive *= 1 - layer.FalsePositiveRate
+		set += layer.LoadFactor * float64(b.m)
+		m += float64(b.m)
+	}
+	stats.LoadFactor = set / m
+	stats.FalsePositiveRate = 1 - noFalsePositive
+	return stats
+}
+
+// stats returns the statistics of a BF with the parameters of b, set of its bits set and memory bytes of bit array
+func (b *BloomFilter) stats(set uint, memory uint) filter.Stats {
+	fill := float64(set) / float64(b.m)
+	return filter.Stats{
+		Count:             b.estimatedCount(set),
+		CountIsEstimate:   true,
+		LoadFactor:        fill,
+		Capacity:          b.n,
+		TargetError:       b.e,
+		FalsePositiveRate: math.Pow(fill, float64(b.k)),
+		MemoryBytes:       memory,
+	}
+}
+
+// estimatedCount returns the number of elements of a BF with set bits set. It saturates when every bit is set, as the estimation is infinite
+func (b *BloomFilter) estimatedCount(set uint) uint {
+	if set >= b.m {
+		set = b.m - 1
+	}
+	return uint(math.Round(b.estimate(set)))
+}
@@ -0,0 +1,191 @@
+package container
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+var (
+	// ErrNotContainer is returned when the data does not start with Magic
+	ErrNotContainer = errors.New("container: not a filter container")
+	// ErrUnsupportedVersion is returned when the container was written with an unknown format version
+	ErrUnsupportedVersion = errors.New("container: unsupported format version")
+	// ErrTruncated is returned when the container is shorter than its header states
+	ErrTruncated = errors.New("container: truncated data")
+	// ErrChecksumMismatch is returned when the stored checksum does not match the content of the container
+	ErrChecksumMismatch = errors.New("container: checksum mismatch")
+	// ErrDescriptorMismatch is returned when the header does not describe the decoded filter
+	ErrDescriptorMismatch = errors.New("container: header does not match the payload")
+)
+
+// UnknownKindError is returned when no decoder has been registered for the kind of a container
+type UnknownKindError struct {
+	Kind Kind
+}
+
+func (e UnknownKindError) Error() string {
+	return fmt.Sprintf("container: no decoder registered for kind %d (%s)", e.Kind, e.Kind)
+}
+
+// Storable is implemented by the filters that can be stored in a container
+type Storable interface {
+	filter.Filter
+	encoding.BinaryMarshaler
+	// Descriptor returns the descriptor of the filter written in the header
+	Descriptor() Descriptor
+}
+
+// Decoder decodes the payload of a container into a filter
+type Decoder func(payload []byte) (Storable, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = make(map[Kind]Decoder)
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Register makes the decoder of a kind available to Open and Read. It is meant to be called from the init function of the filter packages
+func Register(kind Kind, decoder Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[kind] = decoder
+}
+
+// Write writes f to w inside a container
+func Write(w io.Writer, f Storable) (int64, error) {
+	payload, err := f.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	h := Header{
+		Descriptor:    f.Descriptor(),
+		Version:       FormatVersion,
+		PayloadLength: uint64(len(payload)),
+	}
+	h.Checksum = checksum(h, payload)
+	n, err := w.Write(h.encode())
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(payload)
+	return int64(n + m), err
+}
+
+// Read reads a container from r and decodes the filter it stores
+func Read(r io.Reader) (Storable, error) {
+	headerData := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(r, headerData); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+	h, err := ParseHeader(headerData)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := lookupDecoder(h.Kind)
+	if err != nil {
+		return nil, err
+	}
+	// The payload length is not trusted before checking the checksum, so the buffer grows with the data actually read
+	// instead of being allocated from the header
+	var buf bytes.Buffer
+	if h.PayloadLength > math.MaxInt64 {
+		return nil, ErrTruncated
+	}
+	if _, err := io.CopyN(&buf, r, int64(h.PayloadLength)); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+	payload := buf.Bytes()
+	if checksum(h, payload) != h.Checksum {
+		return nil, ErrChecksumMismatch
+	}
+	return decode(h, decoder, payload)
+}
+
+// Save writes f inside a container into the file at path, replacing its content
+func Save(path string, f Storable) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := Write(file, f); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Open loads the filter stored in the container at path into its concrete type
+func Open(path string) (Storable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	h, payload, err := Split(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := lookupDecoder(h.Kind)
+	if err != nil {
+		return nil, err
+	}
+	return decode(h, decoder, payload)
+}
+
+// Split parses the header of a whole container and verifies its checksum. Returns the header and the payload, which aliases data
+func Split(data []byte) (Header, []byte, error) {
+	h, err := ParseHeader(data)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	if uint64(len(data)-HeaderSize) != h.PayloadLength {
+		return Header{}, nil, ErrTruncated
+	}
+	payload := data[HeaderSize:]
+	if checksum(h, payload) != h.Checksum {
+		return Header{}, nil, ErrChecksumMismatch
+	}
+	return h, payload, nil
+}
+
+func decode(h Header, decoder Decoder, payload []byte) (Storable, error) {
+	f, err := decoder(payload)
+	if err != nil {
+		return nil, err
+	}
+	if f.Descriptor() != h.Descriptor {
+		return nil, ErrDescriptorMismatch
+	}
+	return f, nil
+}
+
+func lookupDecoder(kind Kind) (Decoder, error) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	decoder, ok := decoders[kind]
+	if !ok {
+		return nil, UnknownKindError{Kind: kind}
+	}
+	return decoder, nil
+}
+
+// checksum computes the CRC32C of the header, without its checksum field, followed by the payload
+func checksum(h Header, payload []byte) uint32 {
+	h.Checksum = 0
+	crc := crc32.Checksum(h.encode(), castagnoli)
+	return crc32.Update(crc, castagnoli, payload)
+}
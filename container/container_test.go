@@ -0,0 +1,189 @@
+package container_test
+
+import (
+	"ProbabilisticDataStructures/bloomFilter"
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/cuckooFilter"
+	"ProbabilisticDataStructures/quotientFilter"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const size = 1000
+
+func filledFilters() []container.Storable {
+	b := bloomFilter.NewFromSizeAndError(size, 0.01)
+	c := cuckooFilter.NewFromSizeAndError(size, 0.01)
+	q := quotientFilter.NewFromSizeAndError(size, 0.01)
+	s, _ := bloomFilter.NewScalable(size/4, 0.01)
+	filters := []container.Storable{&b, &c, &q, s}
+	for _, f := range filters {
+		for i := 0; i < size; i++ {
+			f.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+	}
+	return filters
+}
+
+func TestSaveAndOpen(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range filledFilters() {
+		kind := f.Descriptor().Kind
+		path := filepath.Join(dir, kind.String())
+		if err := container.Save(path, f); err != nil {
+			t.Fatal(err)
+		}
+		opened, err := container.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened.Descriptor() != f.Descriptor() {
+			t.Errorf("Expected descriptor %+v, Current descriptor %+v", f.Descriptor(), opened.Descriptor())
+		}
+		switch opened.(type) {
+		case *bloomFilter.BloomFilter:
+			if kind != container.KindBloom {
+				t.Errorf("Expected kind %s, Current type %T", kind, opened)
+			}
+		case *bloomFilter.ScalableBloomFilter:
+			if kind != container.KindScalableBloom {
+				t.Errorf("Expected kind %s, Current type %T", kind, opened)
+			}
+		case *cuckooFilter.CuckooFilter:
+			if kind != container.KindCuckoo {
+				t.Errorf("Expected kind %s, Current type %T", kind, opened)
+			}
+		case *quotientFilter.QuotientFilter:
+			if kind != container.KindQuotient {
+				t.Errorf("Expected kind %s, Current type %T", kind, opened)
+			}
+		default:
+			t.Errorf("Unexpected type %T", opened)
+		}
+		for i := 0; i < size; i++ {
+			elem := []byte(fmt.Sprintf("%d", i))
+			if ok := opened.Lookup(elem); !ok {
+				t.Errorf("%s should be in the %s filter.", elem, kind)
+			}
+		}
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	var buf bytes.Buffer
+	filters := filledFilters()
+	for _, f := range filters {
+		if _, err := container.Write(&buf, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range filters {
+		read, err := container.Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read.Descriptor() != f.Descriptor() {
+			t.Errorf("Expected descriptor %+v, Current descriptor %+v", f.Descriptor(), read.Descriptor())
+		}
+	}
+}
+
+func TestOpenDetectsInvalidContainers(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := container.Write(&buf, filledFilters()[0]); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+	cases := []struct {
+		name     string
+		modify   func([]byte) []byte
+		expected error
+	}{
+		{
+			name:     "magic",
+			modify:   func(data []byte) []byte { data[0] = 'X'; return data },
+			expected: container.ErrNotContainer,
+		},
+		{
+			name:     "version",
+			modify:   func(data []byte) []byte { data[4]++; return data },
+			expected: container.ErrUnsupportedVersion,
+		},
+		{
+			name:     "truncated",
+			modify:   func(data []byte) []byte { return data[:len(data)-1] },
+			expected: container.ErrTruncated,
+		},
+		{
+			name:     "payload",
+			modify:   func(data []byte) []byte { data[len(data)-1] ^= 1; return data },
+			expected: container.ErrChecksumMismatch,
+		},
+		{
+			name:     "length",
+			modify:   func(data []byte) []byte { binary.LittleEndian.PutUint64(data[56:], 1<<62); return data },
+			expected: container.ErrTruncated,
+		},
+		{
+			name:     "parameters",
+			modify:   func(data []byte) []byte { data[16]++; return data },
+			expected: container.ErrChecksumMismatch,
+		},
+	}
+	dir := t.TempDir()
+	for _, c := range cases {
+		data := c.modify(append([]byte(nil), valid...))
+		path := filepath.Join(dir, c.name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := container.Open(path); !errors.Is(err, c.expected) {
+			t.Errorf("Corrupted %s: Expected error %v, Current error %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestReadUnknownKind(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := container.Write(&buf, filledFilters()[0]); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	data[6] = 0xff
+	_, err := container.Read(bytes.NewReader(data))
+	var kindErr container.UnknownKindError
+	if !errors.As(err, &kindErr) || kindErr.Kind != 0xff {
+		t.Errorf("Expected an unknown kind error, Current error %v", err)
+	}
+}
+
+func TestReadDetectsCorruptedPayloadLength(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := container.Write(&buf, filledFilters()[0]); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+	payloadLength := binary.LittleEndian.Uint64(valid[56:])
+	cases := []struct {
+		length   uint64
+		expected error
+	}{
+		{1 << 62, container.ErrTruncated},
+		{^uint64(0), container.ErrTruncated},
+		{payloadLength + 1, container.ErrTruncated},
+		{payloadLength - 1, container.ErrChecksumMismatch},
+		{0, container.ErrChecksumMismatch},
+	}
+	for _, c := range cases {
+		data := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint64(data[56:], c.length)
+		if _, err := container.Read(bytes.NewReader(data)); !errors.Is(err, c.expected) {
+			t.Errorf("Payload length %d: Expected error %v, Current error %v", c.length, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package container
+
+import (
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"encoding/binary"
+	"math"
+)
+
+// Magic are the bytes every container starts with
+const Magic = "PDSF"
+
+// FormatVersion is the version of the container layout written by this package
+const FormatVersion = uint16(1)
+
+// HeaderSize is the size (in bytes) of the header. It is a multiple of the word size, so the payload is word aligned
+const HeaderSize = 9 * utils.WordSize
+
+// Kind identifies the concrete filter stored in a container
+type Kind uint8
+
+const (
+	KindBloom Kind = iota + 1
+	KindCuckoo
+	KindQuotient
+	KindScalableBloom
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBloom:
+		return "bloom"
+	case KindCuckoo:
+		return "cuckoo"
+	case KindQuotient:
+		return "quotient"
+	case KindScalableBloom:
+		return "scalable-bloom"
+	}
+	return "unknown"
+}
+
+// HashAlgorithm identifies the hash function used by the stored filter. Its values are those of hasher.Algorithm
+type HashAlgorithm uint8
+
+const (
+	HashMurmur3 = HashAlgorithm(hasher.AlgorithmMurmur3)
+	HashXXHash  = HashAlgorithm(hasher.AlgorithmXXHash)
+	HashFNV1a   = HashAlgorithm(hasher.AlgorithmFNV1a)
+	HashSipHash = HashAlgorithm(hasher.AlgorithmSipHash)
+)
+
+func (a HashAlgorithm) String() string {
+	return hasher.Algorithm(a).String()
+}
+
+// Descriptor describes a filter: its kind, its hash function and its parameters
+type Descriptor struct {
+	Kind Kind
+	Hash HashAlgorithm
+	Seed uint64
+	// N is the capacity of the filter
+	N uint64
+	// M is the size of the filter, in the units of each kind (bits, buckets or slots)
+	M uint64
+	// E is the target false positive error
+	E float64
+	// Params are the kind specific parameters: k and the double hashing flag for Bloom, p and b for Cuckoo, q and r for Quotient,
+	// the growth factor and the bits of the tightening ratio for Scalable Bloom
+	Params [2]uint64
+}
+
+// Header is the header of a container: the descriptor of the filter and its payload length and checksum
+type Header struct {
+	Descriptor
+	Version       uint16
+	PayloadLength uint64
+	Checksum      uint32
+}
+
+func (h Header) encode() []byte {
+	data := make([]byte, HeaderSize)
+	copy(data, Magic)
+	binary.LittleEndian.PutUint16(data[4:], h.Version)
+	data[6] = byte(h.Kind)
+	data[7] = byte(h.Hash)
+	binary.LittleEndian.PutUint64(data[8:], h.Seed)
+	binary.LittleEndian.PutUint64(data[16:], h.N)
+	binary.LittleEndian.PutUint64(data[24:], h.M)
+	binary.LittleEndian.PutUint64(data[32:], math.Float64bits(h.E))
+	binary.LittleEndian.PutUint64(data[40:], h.Params[0])
+	binary.LittleEndian.PutUint64(data[48:], h.Params[1])
+	binary.LittleEndian.PutUint64(data[56:], h.PayloadLength)
+	binary.LittleEndian.PutUint32(data[64:], h.Checksum)
+	return data
+}
+
+// ParseHeader decodes and validates the header at the beginning of data. The payload is not verified
+func ParseHeader(data []byte) (Header, error) {
+	if len(data) < HeaderSize {
+		return Header{}, ErrTruncated
+	}
+	if string(data[:len(Magic)]) != Magic {
+		return Header{}, ErrNotContainer
+	}
+	h := Header{
+		Descriptor: Descriptor{
+			Kind:   Kind(data[6]),
+			Hash:   HashAlgorithm(data[7]),
+			Seed:   binary.LittleEndian.Uint64(data[8:]),
+			N:      binary.LittleEndian.Uint64(data[16:]),
+			M:      binary.LittleEndian.Uint64(data[24:]),
+			E:      math.Float64frombits(binary.LittleEndian.Uint64(data[32:])),
+			Params: [2]uint64{binary.LittleEndian.Uint64(data[40:]), binary.LittleEndian.Uint64(data[48:])},
+		},
+		Version:       binary.LittleEndian.Uint16(data[4:]),
+		PayloadLength: binary.LittleEndian.Uint64(data[56:]),
+		Checksum:      binary.LittleEndian.Uint32(data[64:]),
+	}
+	if h.Version != FormatVersion {
+		return Header{}, ErrUnsupportedVersion
+	}
+	return h, nil
+}
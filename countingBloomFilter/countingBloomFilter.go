@@ -0,0 +1,167 @@
+package countingBloomFilter
+
+import (
+	"ProbabilisticDataStructures/bloomFilter"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"errors"
+)
+
+var _ filter.DeletableFilter = (*CountingBloomFilter)(nil)
+
+// ErrCounterOverflow is returned when inserting an element one of whose counters is already saturated
+var ErrCounterOverflow = errors.New("countingBloomFilter: counter overflow")
+
+// CountingBloomFilter is the struct that represents a Counting Bloom Filter: a Bloom Filter whose bits are replaced
+// by saturating counters packed in words, so elements can also be deleted
+type CountingBloomFilter struct {
+	n      uint
+	m      uint
+	k      uint
+	e      float64
+	seed   uint32
+	hasher hasher.Hasher
+	// doubleHashing derives the k positions from a single 128-bit hash
+	doubleHashing bool
+	counters      utils.PackedArray
+	// max is the value counters saturate at
+	max uint64
+}
+
+// New creates a new Counting Bloom Filter with m counters and k hash functions. It panics if m or k are 0, NewWithSize returns an error instead
+func New(m uint, k uint) CountingBloomFilter {
+	c, err := NewWithSize(m, k)
+	if err != nil {
+		panic(err)
+	}
+	return *c
+}
+
+// NewFromSizeAndError creates a new Counting Bloom Filter that can hold n elements with e false positive error.
+// It panics if n is 0 or e is not between 0 and 1, NewWithOptions returns an error instead
+func NewFromSizeAndError(n uint, e float64) CountingBloomFilter {
+	c, err := NewWithOptions(n, e)
+	if err != nil {
+		panic(err)
+	}
+	return *c
+}
+
+// NewWithOptions creates a new Counting Bloom Filter that can hold n elements with e false positive error, configured with opts.
+// It has as many counters and hash functions as the BF of the same n and e
+func NewWithOptions(n uint, e float64, opts ...Option) (*CountingBloomFilter, error) {
+	if n == 0 {
+		return nil, parameterError("n", n, "capacity must be greater than 0")
+	}
+	if !(e > 0 && e < 1) {
+		return nil, parameterError("e", e, "false positive error must be between 0 and 1 (exclusive)")
+	}
+	sizeM, sizeK := bloomFilter.OptimalSize(n, e)
+	return newCBF(n, e, sizeM, sizeK, opts)
+}
+
+// NewWithSize creates a new Counting Bloom Filter with m counters and k hash functions, configured with opts
+func NewWithSize(m uint, k uint, opts ...Option) (*CountingBloomFilter, error) {
+	if m == 0 {
+		return nil, parameterError("m", m, "size must be greater than 0")
+	}
+	if k == 0 {
+		return nil, parameterError("k", k, "number of hash functions must be greater than 0")
+	}
+	n, e := bloomFilter.Capacity(m, k)
+	return newCBF(n, e, m, k, opts)
+}
+
+// Insert inserts element into CBF. Returns false, leaving CBF unchanged, if one of the counters of element is saturated. Computational time: O(k)
+func (c *CountingBloomFilter) Insert(element []byte) bool {
+	return c.Add(element) == nil
+}
+
+// Add inserts element into CBF. Returns ErrCounterOverflow, leaving CBF unchanged, if one of the counters of element is saturated,
+// as incrementing it would lose count of the elements it holds. Computational time: O(k)
+func (c *CountingBloomFilter) Add(element []byte) error {
+	p := c.positions(element)
+	for i := uint(0); i < c.k; i++ {
+		pos := p.Next()
+		counter := c.counters.Get(pos)
+		if counter == c.max {
+			c.undo(element, i, 1)
+			return ErrCounterOverflow
+		}
+		c.counters.Set(pos, counter+1)
+	}
+	return nil
+}
+
+// Lookup returns true if element may belong to the CBF and false if element does not belong to the CBF. Computational time: O(k)
+func (c *CountingBloomFilter) Lookup(element []byte) bool {
+	return c.Count(element) > 0
+}
+
+// Delete deletes element in CBF. Returns false, leaving CBF unchanged, if element does not belong to the CBF.
+// Deleting an element that has not been inserted may cause false negatives. Computational time: O(k)
+func (c *CountingBloomFilter) Delete(element []byte) bool {
+	p := c.positions(element)
+	for i := uint(0); i < c.k; i++ {
+		pos := p.Next()
+		counter := c.counters.Get(pos)
+		if counter == 0 {
+			c.undo(element, i, -1)
+			return false
+		}
+		c.counters.Set(pos, counter-1)
+	}
+	return true
+}
+
+// Count returns an estimation of the number of times element has been inserted, the minimum of its counters.
+// It never underestimates it, but may overestimate it as false positives do. Computational time: O(k)
+func (c *CountingBloomFilter) Count(element []byte) uint64 {
+	p := c.positions(element)
+	count := c.max
+	for i := uint(0); i < c.k && count > 0; i++ {
+		if counter := c.counters.Get(p.Next()); counter < count {
+			count = counter
+		}
+	}
+	return count
+}
+
+// TotalSize returns the size (in bytes) of the packed counters that represent CBF, that is m counters rounded up to words.
+func (c *CountingBloomFilter) TotalSize() uint {
+	return uint(len(c.counters.Words())) * utils.WordSize
+}
+
+// undo reverts the change of delta made to the first steps counters of element, so a failed Add or Delete leaves CBF unchanged.
+// It visits the positions in the same order as they were changed, so positions repeated among the k are handled
+func (c *CountingBloomFilter) undo(element []byte, steps uint, delta int) {
+	p := c.positions(element)
+	for i := uint(0); i < steps; i++ {
+		pos := p.Next()
+		c.counters.Set(pos, uint64(int64(c.counters.Get(pos))-int64(delta)))
+	}
+}
+
+func newCBF(n uint, e float64, m uint, k uint, opts []Option) (*CountingBloomFilter, error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &CountingBloomFilter{
+		n:             n,
+		e:             e,
+		m:             m,
+		k:             k,
+		seed:          cfg.seed,
+		hasher:        cfg.hasher,
+		doubleHashing: cfg.doubleHashing,
+		counters:      utils.NewPackedArray(m, cfg.counterWidth),
+		max:           utils.Mask(cfg.counterWidth),
+	}, nil
+}
+
+// positions returns the iterator over the k positions of element, the same positions a BF with the same parameters uses
+func (c *CountingBloomFilter) positions(element []byte) bloomFilter.Positions {
+	return bloomFilter.NewPositions(element, c.m, c.seed, c.hasher, c.doubleHashing)
+}
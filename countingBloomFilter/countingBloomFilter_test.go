@@ -0,0 +1,209 @@
+package countingBloomFilter
+
+import (
+	"ProbabilisticDataStructures/bloomFilter"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/filter/conformance"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestNewFromSizeAndError(t *testing.T) {
+	c := NewFromSizeAndError(100, 0.03)
+	if c.n != 100 || c.m != 730 || c.k != 6 || c.e != 0.03 {
+		t.Errorf("Expected n = 100, m = 730, k = 6 and e = 0.03, got n = %d, m = %d, k = %d and e = %v", c.n, c.m, c.k, c.e)
+	}
+	if c.counters.Width() != defaultCounterWidth || c.max != 15 {
+		t.Errorf("Expected %d-bit counters saturating at 15, got %d-bit counters saturating at %d", defaultCounterWidth, c.counters.Width(), c.max)
+	}
+	if c.TotalSize() != 368 {
+		t.Errorf("Expected 730 4-bit counters to take 368 bytes, got %d", c.TotalSize())
+	}
+}
+
+func TestNewWithSizeMatchesBloomFilter(t *testing.T) {
+	c := New(730, 6)
+	n, e := bloomFilter.Capacity(730, 6)
+	if c.n != n || c.e != e {
+		t.Errorf("Expected capacity %d and error %v, got %d and %v", n, e, c.n, c.e)
+	}
+}
+
+func TestNewWithOptionsRejectsInvalidParameters(t *testing.T) {
+	cases := []struct {
+		n    uint
+		e    float64
+		opts []Option
+	}{
+		{0, 0.01, nil},
+		{1000, 0, nil},
+		{1000, 1, nil},
+		{1000, math.NaN(), nil},
+		{1000, 0.01, []Option{WithHasher(nil)}},
+		{1000, 0.01, []Option{WithCounterWidth(1)}},
+		{1000, 0.01, []Option{WithCounterWidth(65)}},
+	}
+	for _, c := range cases {
+		if _, err := NewWithOptions(c.n, c.e, c.opts...); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("n = %d and e = %v should return an invalid parameter error, got %v", c.n, c.e, err)
+		}
+	}
+	for _, c := range [][2]uint{{0, 3}, {1000, 0}} {
+		if _, err := NewWithSize(c[0], c[1]); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("m = %d and k = %d should return an invalid parameter error, got %v", c[0], c[1], err)
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		c := NewFromSizeAndError(n, e)
+		return &c
+	})
+}
+
+func TestConformanceWithDoubleHashingAndWideCounters(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		c, err := NewWithOptions(n, e, WithDoubleHashing(), WithCounterWidth(64))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	})
+}
+
+func TestDeleteKeepsOtherElements(t *testing.T) {
+	c := NewFromSizeAndError(1000, 0.001)
+	for i := 0; i < 1000; i++ {
+		c.Insert([]byte(fmt.Sprint(i)))
+	}
+	for i := 0; i < 1000; i += 2 {
+		if !c.Delete([]byte(fmt.Sprint(i))) {
+			t.Fatalf("%d should have been deleted", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		found := c.Lookup([]byte(fmt.Sprint(i)))
+		if i%2 == 1 && !found {
+			t.Errorf("%d should be in.", i)
+		}
+		if i%2 == 0 && found {
+			falsePositives++
+		}
+	}
+	if falsePositives > 10 {
+		t.Errorf("Expected around 1 deleted element to be a false positive, got %d", falsePositives)
+	}
+}
+
+func TestDeleteOfMissingElementLeavesCountersUnchanged(t *testing.T) {
+	c := NewFromSizeAndError(100, 0.01)
+	c.Insert([]byte("Hello World"))
+	words := append([]uint64(nil), c.counters.Words()...)
+	if c.Delete([]byte("Bye")) {
+		t.Errorf("Bye should not be deleted, as it was never inserted")
+	}
+	for i, w := range c.counters.Words() {
+		if w != words[i] {
+			t.Fatalf("A failed delete should leave the counters unchanged")
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	c := NewFromSizeAndError(100, 0.001)
+	elem := []byte("Hello World")
+	for i := uint64(1); i <= 10; i++ {
+		c.Insert(elem)
+		if count := c.Count(elem); count != i {
+			t.Errorf("Expected count %d, got %d", i, count)
+		}
+	}
+	c.Delete(elem)
+	if count := c.Count(elem); count != 9 {
+		t.Errorf("Expected count 9 after a delete, got %d", count)
+	}
+	if count := c.Count([]byte("Bye")); count != 0 {
+		t.Errorf("Expected count 0 of an element never inserted, got %d", count)
+	}
+}
+
+func TestStatsCountIsAnEstimate(t *testing.T) {
+	c := NewFromSizeAndError(100, 0.2)
+	for i := 0; i < 100; i++ {
+		c.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	if stats := c.Stats(); !stats.CountIsEstimate || stats.Count != 100 {
+		t.Fatalf("Expected an estimated count of 100, got %+v", stats)
+	}
+	// Deleting a false positive decrements the counters of the inserted elements it collides with
+	for i := 100; ; i++ {
+		if elem := []byte(fmt.Sprintf("%d", i)); c.Lookup(elem) {
+			c.Delete(elem)
+			break
+		}
+	}
+	if count := c.Stats().Count; count != 99 {
+		t.Errorf("Expected an estimated count of 99 after deleting a false positive, got %d", count)
+	}
+}
+
+func TestAddReportsCounterOverflow(t *testing.T) {
+	c, err := NewWithOptions(100, 0.01, WithCounterWidth(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elem := []byte("Hello World")
+	for i := 0; i < 3; i++ {
+		if err := c.Add(elem); err != nil {
+			t.Fatalf("Insert %d should not overflow 2-bit counters, got %v", i, err)
+		}
+	}
+	words := append([]uint64(nil), c.counters.Words()...)
+	if err := c.Add(elem); !errors.Is(err, ErrCounterOverflow) {
+		t.Fatalf("A 4th insert should overflow 2-bit counters, got %v", err)
+	}
+	if c.Insert(elem) {
+		t.Errorf("Insert should return false when a counter overflows")
+	}
+	for i, w := range c.counters.Words() {
+		if w != words[i] {
+			t.Fatalf("An overflowing insert should leave the counters unchanged")
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if !c.Delete(elem) {
+			t.Fatalf("Delete %d should succeed", i)
+		}
+	}
+	if c.Lookup(elem) {
+		t.Errorf("Hello World should not be in after deleting it as many times as it was inserted")
+	}
+}
+
+func TestRepeatedPositionsAreUndone(t *testing.T) {
+	// With m = 1 every hash function maps to the same counter, so an insert fails halfway through
+	c, err := NewWithSize(1, 3, WithCounterWidth(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elem := []byte("Hello World")
+	if !c.Insert(elem) {
+		t.Fatalf("The first insert should fit in the counter")
+	}
+	if c.Insert(elem) {
+		t.Fatalf("The second insert should overflow the counter")
+	}
+	if count := c.Count(elem); count != 3 {
+		t.Errorf("Expected the counter to stay at 3 after the failed insert, got %d", count)
+	}
+	if !c.Delete(elem) || c.Lookup(elem) {
+		t.Errorf("Deleting Hello World should empty the counter")
+	}
+	if c.Delete(elem) {
+		t.Errorf("Deleting Hello World twice should fail")
+	}
+}
@@ -0,0 +1,79 @@
+package countingBloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+)
+
+const defaultCounterWidth = 4
+
+// Option configures a CBF created with NewWithOptions or NewWithSize
+type Option func(*config) error
+
+type config struct {
+	seed          uint32
+	hasher        hasher.Hasher
+	doubleHashing bool
+	counterWidth  uint
+}
+
+func newConfig(opts []Option) (config, error) {
+	cfg := config{
+		hasher:       hasher.Murmur3{},
+		counterWidth: defaultCounterWidth,
+	}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// WithSeed sets the seed of the first hash function. The i-th hash function uses seed+i. The default seed is 0
+func WithSeed(seed uint32) Option {
+	return func(c *config) error {
+		c.seed = seed
+		return nil
+	}
+}
+
+// WithHasher sets the hash function used to compute the k positions. The default is hasher.Murmur3
+func WithHasher(h hasher.Hasher) Option {
+	return func(c *config) error {
+		if h == nil {
+			return parameterError("hasher", h, "must not be nil")
+		}
+		c.hasher = h
+		return nil
+	}
+}
+
+// WithDoubleHashing derives the k positions from a single 128-bit hash using enhanced double hashing, as bloomFilter.WithDoubleHashing
+func WithDoubleHashing() Option {
+	return func(c *config) error {
+		c.doubleHashing = true
+		return nil
+	}
+}
+
+// WithCounterWidth sets the width (in bits) of every counter, between 2 and 64. The default is 4 bits, whose counters saturate at 15
+func WithCounterWidth(width uint) Option {
+	return func(c *config) error {
+		if width < 2 || width > utils.Machine64Bits {
+			return parameterError("counterWidth", width, "counter width must be between 2 and 64 bits")
+		}
+		c.counterWidth = width
+		return nil
+	}
+}
+
+func parameterError(parameter string, value interface{}, reason string) error {
+	return &filter.ParameterError{
+		Filter:    "countingBloomFilter",
+		Parameter: parameter,
+		Value:     value,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,30 @@
+package countingBloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"math"
+)
+
+var _ filter.StatsReporter = (*CountingBloomFilter)(nil)
+
+// Stats returns the statistics of CBF. Its count, the sum of the counters divided by k, is an estimate: deleting a false positive
+// decrements the counters of the elements it collides with, so the sum drifts from k times the inserted elements. Computational time: O(m)
+func (c *CountingBloomFilter) Stats() filter.Stats {
+	sum, used := uint64(0), uint(0)
+	for i := uint(0); i < c.m; i++ {
+		if counter := c.counters.Get(i); counter != 0 {
+			sum += counter
+			used++
+		}
+	}
+	fill := float64(used) / float64(c.m)
+	return filter.Stats{
+		Count:             uint(sum / uint64(c.k)),
+		CountIsEstimate:   true,
+		LoadFactor:        fill,
+		Capacity:          c.n,
+		TargetError:       c.e,
+		FalsePositiveRate: math.Pow(fill, float64(c.k)),
+		MemoryBytes:       c.TotalSize(),
+	}
+}
@@ -0,0 +1,82 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+)
+
+const (
+	// batchChunkBits is the log2 of the number of elements whose first buckets are sorted together by the batch operations
+	batchChunkBits = 12
+	batchChunk     = 1 << batchChunkBits
+	// batchRegions is the number of regions of the table the elements of a chunk are grouped by
+	batchRegions = 1024
+)
+
+var (
+	_ filter.BatchFilter  = (*CuckooFilter)(nil)
+	_ filter.BatchDeleter = (*CuckooFilter)(nil)
+)
+
+// hashedElement is the index of an element of a batch with its two buckets and its fingerprint
+type hashedElement struct {
+	index int
+	i     uint
+	j     uint
+	f     uint64
+}
+
+// InsertBatch inserts elements into CF grouped by their first bucket.
+// Returns a *filter.BatchError holding the elements that could not be inserted, nil if all of them have been inserted
+func (c *CuckooFilter) InsertBatch(elements [][]byte) error {
+	ok := make([]bool, len(elements))
+	c.forEachSortedElement(elements, func(e hashedElement) {
+		if ok[e.index] = c.insert(e.i, e.j, e.f); ok[e.index] {
+			c.count++
+		}
+	})
+	return filter.BatchResult("cuckooFilter: insert", ok)
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in CF, visiting them grouped by their first bucket
+func (c *CuckooFilter) LookupBatch(elements [][]byte, results []bool) {
+	c.forEachSortedElement(elements, func(e hashedElement) {
+		results[e.index] = c.lookup(e.i, e.j, e.f)
+	})
+}
+
+// DeleteBatch deletes elements in CF grouped by their first bucket.
+// Returns a *filter.BatchError holding the elements that were not found, nil if all of them have been deleted
+func (c *CuckooFilter) DeleteBatch(elements [][]byte) error {
+	ok := make([]bool, len(elements))
+	c.forEachSortedElement(elements, func(e hashedElement) {
+		ok[e.index] = c.delete(e.i, e.j, e.f)
+	})
+	return filter.BatchResult("cuckooFilter: delete", ok)
+}
+
+// forEachSortedElement hashes elements in chunks and calls fn with every element of a chunk grouped by region of the table,
+// in increasing order of region, so the table is scanned mostly sequentially. It only reads the parameters of c, so it can run concurrently
+func (c *CuckooFilter) forEachSortedElement(elements [][]byte, fn func(e hashedElement)) {
+	hashed := make([]hashedElement, 0, batchChunk)
+	keys := make([]uint64, 0, batchChunk)
+	sorted := make([]uint64, batchChunk)
+	counts := make([]int, batchRegions+1)
+	shift := utils.RegionShift(uint64(c.m-1)<<batchChunkBits|(batchChunk-1), batchRegions)
+	for start := 0; start < len(elements); start += batchChunk {
+		end := start + batchChunk
+		if end > len(elements) {
+			end = len(elements)
+		}
+		hashed, keys = hashed[:0], keys[:0]
+		for index := start; index < end; index++ {
+			i, j, f := c.computeHashPositionsAndFingerprint(elements[index])
+			hashed = append(hashed, hashedElement{index: index, i: i, j: j, f: f})
+			keys = append(keys, uint64(i)<<batchChunkBits|uint64(index-start))
+		}
+		utils.RegionSort(keys, sorted, shift, counts)
+		for _, key := range sorted[:len(keys)] {
+			fn(hashed[key&(batchChunk-1)])
+		}
+	}
+}
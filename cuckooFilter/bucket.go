@@ -1,52 +1,73 @@
 package cuckooFilter
 
-import "github.com/bits-and-blooms/bitset"
+import "ProbabilisticDataStructures/utils"
 
-type bucket []*bitset.BitSet
+// empty is the fingerprint value that marks an empty slot
+const empty = uint64(0)
 
-func (b *bucket) deletePos(pos uint) {
-	(*b)[pos] = nil
+// bucket is a view of the slots of a bucket inside the packed table of the CF.
+// The slots of a semi-sorted bucket are decoded together, so its positions change whenever it is modified
+type bucket struct {
+	table      utils.PackedArray
+	offset     uint
+	size       uint
+	semiSorted bool
+	// lowBits are the bits of every fingerprint of a semi-sorted bucket stored apart from the index of its nibbles
+	lowBits uint
 }
 
-func (b bucket) isElement(f *bitset.BitSet) (bool, uint) {
-	pos := uint(0)
-	for _, elem := range b {
-		if elem != nil && elem.Equal(f) {
+func (b bucket) deletePos(pos uint) {
+	b.AddInPosition(empty, pos)
+}
+
+func (b bucket) isElement(f uint64) (bool, uint) {
+	if b.semiSorted {
+		for pos, g := range b.load() {
+			if g == f {
+				return true, uint(pos)
+			}
+		}
+		return false, 0
+	}
+	for pos := uint(0); pos < b.size; pos++ {
+		if b.table.Get(b.offset+pos) == f {
 			return true, pos
 		}
-		pos++
 	}
 	return false, 0
 }
 
 func (b bucket) isFull() bool {
-	for _, elem := range b {
-		if elem == nil {
-			return false
-		}
+	ok, _ := b.isElement(empty)
+	return !ok
+}
+
+func (b bucket) Add(f uint64) {
+	if ok, pos := b.isElement(empty); ok {
+		b.AddInPosition(f, pos)
 	}
-	return true
 }
 
-func (b *bucket) Add(f *bitset.BitSet) {
-	if f == nil {
+func (b bucket) AddInPosition(f uint64, pos uint) {
+	if b.semiSorted {
+		fingerprints := b.load()
+		fingerprints[pos] = f
+		b.store(fingerprints)
 		return
 	}
-	for i, _ := range *b {
-		if (*b)[i] == nil {
-			(*b)[i] = bitset.New(f.Len())
-			f.Copy((*b)[i])
-			return
-		}
-	}
+	b.table.Set(b.offset+pos, f)
 }
 
-func (b *bucket) AddInPosition(f *bitset.BitSet, pos uint) {
-	f.Copy((*b)[pos])
+// replace replaces a copy of fingerprint old by f
+func (b bucket) replace(old uint64, f uint64) {
+	if ok, pos := b.isElement(old); ok {
+		b.AddInPosition(f, pos)
+	}
 }
 
-func (b bucket) Get(pos uint) *bitset.BitSet {
-	n := bitset.New(b[pos].Len())
-	b[pos].Copy(n)
-	return n
+func (b bucket) Get(pos uint) uint64 {
+	if b.semiSorted {
+		return b.load()[pos]
+	}
+	return b.table.Get(b.offset + pos)
 }
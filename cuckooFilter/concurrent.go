@@ -0,0 +1,107 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"sync"
+)
+
+var (
+	_ filter.DeletableFilter = (*ConcurrentCuckooFilter)(nil)
+	_ filter.UniqueInserter  = (*ConcurrentCuckooFilter)(nil)
+	_ filter.BatchFilter     = (*ConcurrentCuckooFilter)(nil)
+	_ filter.BatchDeleter    = (*ConcurrentCuckooFilter)(nil)
+	_ container.Storable     = (*ConcurrentCuckooFilter)(nil)
+)
+
+// ConcurrentCuckooFilter is a Cuckoo Filter safe for concurrent use by multiple goroutines.
+// Lookups proceed in parallel, while inserts and deletes hold the filter exclusively
+type ConcurrentCuckooFilter struct {
+	mu     sync.RWMutex
+	filter *CuckooFilter
+	// parallelism is the number of goroutines LookupBatch fans out to
+	parallelism int
+}
+
+// NewConcurrent wraps c so it can be shared between goroutines. c must not be used directly afterwards
+func NewConcurrent(c *CuckooFilter) *ConcurrentCuckooFilter {
+	return &ConcurrentCuckooFilter{filter: c}
+}
+
+// SetParallelism sets the number of goroutines LookupBatch splits its elements between. By default it does not fan out.
+// It must be called before sharing the filter
+func (c *ConcurrentCuckooFilter) SetParallelism(goroutines int) {
+	c.parallelism = goroutines
+}
+
+// Insert inserts element into CF. Returns true if element has been inserted, false otherwise. Amortized computational time: O(1)
+func (c *ConcurrentCuckooFilter) Insert(element []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.Insert(element)
+}
+
+// InsertUnique inserts element into CF if element is not already inserted, checking and inserting atomically.
+// Returns true if element has been inserted or already exists, false otherwise. Amortized computational time: O(1)
+func (c *ConcurrentCuckooFilter) InsertUnique(element []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.InsertUnique(element)
+}
+
+// Lookup returns true if element may belong to the CF and false if element does not belong to the CF. Computational time: O(1)
+func (c *ConcurrentCuckooFilter) Lookup(element []byte) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.Lookup(element)
+}
+
+// Delete deletes element in the filter. Returns true if element has been deleted, false otherwise. Computational time: O(1)
+func (c *ConcurrentCuckooFilter) Delete(element []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.Delete(element)
+}
+
+// InsertBatch inserts elements into CF, holding the filter exclusively once for the whole batch.
+// Returns a *filter.BatchError holding the elements that could not be inserted, nil if all of them have been inserted
+func (c *ConcurrentCuckooFilter) InsertBatch(elements [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.InsertBatch(elements)
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in CF, splitting the elements between the goroutines set by SetParallelism
+func (c *ConcurrentCuckooFilter) LookupBatch(elements [][]byte, results []bool) {
+	utils.ParallelChunks(len(elements), c.parallelism, func(start int, end int) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		c.filter.LookupBatch(elements[start:end], results[start:end])
+	})
+}
+
+// DeleteBatch deletes elements in CF, holding the filter exclusively once for the whole batch.
+// Returns a *filter.BatchError holding the elements that were not found, nil if all of them have been deleted
+func (c *ConcurrentCuckooFilter) DeleteBatch(elements [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.DeleteBatch(elements)
+}
+
+// TotalSize returns the size (in bytes) of the packed table that represents CF.
+func (c *ConcurrentCuckooFilter) TotalSize() uint {
+	return c.filter.TotalSize()
+}
+
+// Descriptor returns the description of CF written in the header of a container
+func (c *ConcurrentCuckooFilter) Descriptor() container.Descriptor {
+	return c.filter.Descriptor()
+}
+
+// MarshalBinary encodes a snapshot of CF, with the same encoding as CuckooFilter.MarshalBinary
+func (c *ConcurrentCuckooFilter) MarshalBinary() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.MarshalBinary()
+}
@@ -0,0 +1,31 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/hasher"
+)
+
+var _ container.Storable = (*CuckooFilter)(nil)
+
+func init() {
+	container.Register(container.KindCuckoo, func(payload []byte) (container.Storable, error) {
+		c := new(CuckooFilter)
+		if err := c.UnmarshalBinary(payload); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
+// Descriptor returns the description of CF written in the header of a container. Its parameters are p and b
+func (c *CuckooFilter) Descriptor() container.Descriptor {
+	return container.Descriptor{
+		Kind:   container.KindCuckoo,
+		Hash:   container.HashAlgorithm(hasher.AlgorithmOf(c.hasher)),
+		Seed:   uint64(c.seed),
+		N:      uint64(c.n),
+		M:      uint64(c.m),
+		E:      c.e,
+		Params: [2]uint64{uint64(c.p), uint64(c.b)},
+	}
+}
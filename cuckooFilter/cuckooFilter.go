@@ -1,49 +1,145 @@
 package cuckooFilter
 
 import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
 	"ProbabilisticDataStructures/utils"
 	"encoding/binary"
+	"fmt"
 	"math"
+	"math/bits"
 	"math/rand"
-
-	"github.com/bits-and-blooms/bitset"
-	"github.com/spaolacci/murmur3"
 )
 
 const (
-	maxIterations = 500
-	b             = 4
-	loadFactor    = 0.95
-	defaultP      = uint(8)
-	defaultSeed   = uint32(1)
+	defaultMaxKicks   = uint(500)
+	defaultBucketSize = uint(4)
+	defaultP          = uint(8)
+	defaultSeed       = uint32(1)
+	defaultStashSize  = uint(4)
+	defaultRandSeed   = uint64(1)
+	// maxStashSize bounds the stash, as lookups scan it linearly
+	maxStashSize = uint(64)
+	// maxP is the widest supported fingerprint, so the 64-bit hash leaves at least 32 bits to choose the bucket
+	maxP = uint(32)
+)
+
+// loadFactors are the load factors reachable before inserts start failing, by bucket size
+var loadFactors = map[uint]float64{
+	1: 0.5,
+	2: 0.84,
+	4: 0.95,
+	8: 0.98,
+}
+
+var (
+	_ filter.DeletableFilter = (*CuckooFilter)(nil)
+	_ filter.UniqueInserter  = (*CuckooFilter)(nil)
 )
 
 type CuckooFilter struct {
-	n       uint
-	m       uint
-	p       uint
-	seed    uint32
-	count   uint
-	buckets []bucket
+	n uint
+	m uint
+	p uint
+	b uint
+	// e is the target false positive error, the one given to NewWithOptions or else the one implied by p and b
+	e        float64
+	maxKicks uint
+	seed     uint32
+	count    uint
+	hasher   hasher.Hasher
+	// table holds the m*b fingerprints of p bits, bucket after bucket, in slots of p-1 bits when semi-sorted. A zero fingerprint marks an empty slot
+	table      utils.PackedArray
+	semiSorted bool
+	// stash holds up to stashSize fingerprints evicted by inserts that ran out of kicks, so no inserted element is lost
+	stashSize uint
+	stash     []stashed
+	eviction  EvictionStrategy
+	// random chooses the fingerprints evicted by random walks, so the same inserts build the same table
+	random rand.Source
+	// kicks records the evictions of the current insert, so they can be rolled back
+	kicks []pathStep
+	// nodes are the buckets reached by the current breadth-first search
+	nodes []searchNode
+}
+
+// stashed is a fingerprint f evicted from the table, one of whose buckets is bucket
+type stashed struct {
+	bucket uint
+	f      uint64
+}
+
+// pathStep is a fingerprint f in slot pos of bucket that will be relocated to its alternative bucket
+type pathStep struct {
+	bucket uint
+	pos    uint
+	f      uint64
 }
 
-// New creates a new Cuckoo Filter with size m and fingerprint size p. If p is not provided, the default p is 8
+// New creates a new Cuckoo Filter with size m, where op[0] is the fingerprint size p (8 by default) and op[1] the seed.
+// It panics if the parameters are not valid, NewWithSize returns an error instead
 func New(m uint, op ...uint) CuckooFilter {
-	n := computeCapacity(m)
-	return newCF(n, m, op...)
+	c, err := NewWithSize(m, legacyOptions(op)...)
+	if err != nil {
+		panic(err)
+	}
+	return *c
 }
 
+// NewFromSize creates a new Cuckoo Filter that can hold n elements, where op[0] is the fingerprint size p (8 by default) and op[1] the seed.
+// It panics if the parameters are not valid, NewWithOptions returns an error instead
 func NewFromSize(n uint, op ...uint) CuckooFilter {
-	m := computeSizeM(n)
-	return newCF(n, m, op...)
+	cfg, err := newConfig(legacyOptions(op))
+	if err != nil {
+		panic(err)
+	}
+	c, err := newWithCapacity(n, 0, cfg)
+	if err != nil {
+		panic(err)
+	}
+	return *c
+}
+
+// NewFromSizeAndError creates a new Cuckoo Filter that can hold n elements with e false positive error, where op[0] is the seed.
+// It panics if the parameters are not valid, NewWithOptions returns an error instead
+func NewFromSizeAndError(n uint, e float64, op ...uint) CuckooFilter {
+	var opts []Option
+	if len(op) > 0 {
+		opts = append(opts, WithSeed(uint32(op[0])))
+	}
+	c, err := NewWithOptions(n, e, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return *c
+}
+
+// NewWithOptions creates a new Cuckoo Filter that can hold n elements with e false positive error, configured with opts.
+// The fingerprint size is computed from e and the number of buckets unless WithFingerprintBits is given
+func NewWithOptions(n uint, e float64, opts ...Option) (*CuckooFilter, error) {
+	if !(e > 0 && e < 1) {
+		return nil, parameterError("e", e, "false positive error must be between 0 and 1 (exclusive)")
+	}
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.p == 0 {
+		cfg.p = computeSizeP(e, computeSizeM(n, cfg.b), cfg.b)
+	}
+	return newWithCapacity(n, e, cfg)
 }
 
-// NewFromSizeAndError creates a new Cuckoo Filter that can hold n elements with e false positive error
-func NewFromSizeAndError(n uint, e float64, op... uint) CuckooFilter {
-	m := computeSizeM(n)
-	p := computeSizeP(e)
-	newOp := append([]uint{p}, op...)
-	return newCF(n, m, newOp...)
+// NewWithSize creates a new Cuckoo Filter with m buckets, configured with opts. m must be a power of 2
+func NewWithSize(m uint, opts ...Option) (*CuckooFilter, error) {
+	if m == 0 || m&(m-1) != 0 {
+		return nil, parameterError("m", m, "number of buckets must be a power of 2")
+	}
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return newCF(computeCapacity(m, cfg.b), m, 0, cfg)
 }
 
 // Insert inserts element into CF. Returns true if element has been inserted, false otherwise. Amortized computational time: O(1)
@@ -67,135 +163,232 @@ func (c *CuckooFilter) InsertUnique(element []byte) bool {
 // Lookup returns true if element may belong to the CF and false if element does not belong to the CF. Computational time: O(1)
 func (c *CuckooFilter) Lookup(element []byte) bool {
 	i, j, f := c.computeHashPositionsAndFingerprint(element)
-	if ok, _ := c.buckets[i].isElement(f); ok {
-		return true
-	}
-	if ok, _ := c.buckets[j].isElement(f); ok {
-		return true
-	}
-	return false
+	return c.lookup(i, j, f)
 }
 
 // Delete deletes element in the filter. Returns true if element has been deleted, false otherwise. Computational time: O(1)
 func (c *CuckooFilter) Delete(element []byte) bool {
 	i, j, f := c.computeHashPositionsAndFingerprint(element)
-	if ok, pos := c.buckets[i].isElement(f); ok {
-		c.buckets[i].deletePos(pos)
+	return c.delete(i, j, f)
+}
+
+// TotalSize returns the size (in bytes) of the packed table that represents CF, that is m*b*p bits (m*b*(p-1) when semi-sorted) rounded up to words.
+func (c *CuckooFilter) TotalSize() uint {
+	return uint(len(c.table.Words())) * utils.WordSize
+}
+
+func (c *CuckooFilter) lookup(i uint, j uint, f uint64) bool {
+	if ok, _ := c.bucket(i).isElement(f); ok {
 		return true
 	}
-	if ok, pos := c.buckets[j].isElement(f); ok {
-		c.buckets[j].deletePos(pos)
+	if ok, _ := c.bucket(j).isElement(f); ok {
+		return true
+	}
+	return c.stashed(i, j, f) >= 0
+}
+
+// delete deletes f from bucket i or j, or else from the stash. Deleting from the table frees a slot a stashed fingerprint may move to
+func (c *CuckooFilter) delete(i uint, j uint, f uint64) bool {
+	for _, k := range [2]uint{i, j} {
+		if ok, pos := c.bucket(k).isElement(f); ok {
+			c.bucket(k).deletePos(pos)
+			c.count--
+			c.unstash()
+			return true
+		}
+	}
+	if s := c.stashed(i, j, f); s >= 0 {
+		c.removeStashed(s)
+		c.count--
 		return true
 	}
 	return false
 }
 
-// TotalSize returns an estimation (in bytes) of the size of the array that represents CF.
-func (c CuckooFilter) TotalSize() uint {
-	sizeP := c.p/utils.ByteSize
-	if c.p % utils.ByteSize != 0 {
-		sizeP++
+// stashed returns the index in the stash of f of buckets i and j, -1 if it is not stashed
+func (c *CuckooFilter) stashed(i uint, j uint, f uint64) int {
+	for s, e := range c.stash {
+		if e.f == f && (e.bucket == i || e.bucket == j) {
+			return s
+		}
+	}
+	return -1
+}
+
+func (c *CuckooFilter) removeStashed(s int) {
+	last := len(c.stash) - 1
+	c.stash[s] = c.stash[last]
+	c.stash = c.stash[:last]
+}
+
+// unstash moves the stashed fingerprints whose buckets have a free slot back into the table
+func (c *CuckooFilter) unstash() {
+	for s := len(c.stash) - 1; s >= 0; s-- {
+		e := c.stash[s]
+		for _, k := range [2]uint{e.bucket, c.getAlternativePosition(e.bucket, e.f)} {
+			if !c.bucket(k).isFull() {
+				c.bucket(k).Add(e.f)
+				c.removeStashed(s)
+				break
+			}
+		}
 	}
-	return c.m * b * sizeP
 }
 
-func newCF(n uint, m uint, p ...uint) CuckooFilter {
-	fingerprintSize := defaultP
-	if len(p) > 0 {
-		fingerprintSize = p[0]
+// newWithCapacity creates a CF that can hold n elements with e false positive error, or with the error implied by its fingerprint
+// size when e is 0
+func newWithCapacity(n uint, e float64, cfg config) (*CuckooFilter, error) {
+	if n == 0 {
+		return nil, parameterError("n", n, "capacity must be greater than 0")
 	}
-	seed := defaultSeed
-	if len(p) > 1 {
-		seed = uint32(p[1])
+	return newCF(n, computeSizeM(n, cfg.b), e, cfg)
+}
+
+func newCF(n uint, m uint, e float64, cfg config) (*CuckooFilter, error) {
+	if cfg.p == 0 {
+		cfg.p = defaultP
 	}
-	return CuckooFilter{
-		n: n,
-		m: m,
-		p: fingerprintSize,
-		seed: seed,
-		buckets: func(m uint) []bucket {
-			l := make([]bucket, m)
-			for i, _ := range l {
-				l[i] = make(bucket, b)
-			}
-			return l
-		}(m),
+	if cfg.p > maxP {
+		return nil, parameterError("p", cfg.p, fmt.Sprintf("fingerprint size must be between 1 and %d bits", maxP))
+	}
+	if cfg.semiSorted {
+		if cfg.b != semiSortedBucketSize || cfg.p < minSemiSortedP {
+			return nil, parameterError("semiSorting", cfg.semiSorted, fmt.Sprintf("semi-sorting needs buckets of %d fingerprints of at least %d bits", semiSortedBucketSize, minSemiSortedP))
+		}
+		initSemiSort()
+	}
+	c := &CuckooFilter{
+		n:          n,
+		m:          m,
+		p:          cfg.p,
+		b:          cfg.b,
+		e:          e,
+		maxKicks:   cfg.maxKicks,
+		seed:       cfg.seed,
+		hasher:     cfg.hasher,
+		table:      utils.NewPackedArray(m*cfg.b, slotBits(cfg.p, cfg.semiSorted)),
+		semiSorted: cfg.semiSorted,
+		stashSize:  cfg.stashSize,
+		eviction:   cfg.eviction,
+		random:     cfg.random,
+	}
+	if c.e == 0 {
+		c.e = c.computeError()
 	}
+	return c, nil
 }
 
-func (c *CuckooFilter) insert(i uint, j uint, f *bitset.BitSet) bool {
-	if !c.buckets[i].isFull() {
-		c.buckets[i].Add(f)
+func (c *CuckooFilter) bucket(i uint) bucket {
+	b := bucket{
+		table:  c.table,
+		offset: i * c.b,
+		size:   c.b,
+	}
+	if c.semiSorted {
+		b.semiSorted, b.lowBits = true, c.p-nibbleBits
+	}
+	return b
+}
+
+// insert adds f to bucket i or j, evicting fingerprints to their alternative buckets with the eviction strategy of CF when both are full
+func (c *CuckooFilter) insert(i uint, j uint, f uint64) bool {
+	if !c.bucket(i).isFull() {
+		c.bucket(i).Add(f)
 		return true
 	}
-	if !c.buckets[j].isFull() {
-		c.buckets[j].Add(f)
+	if !c.bucket(j).isFull() {
+		c.bucket(j).Add(f)
 		return true
 	}
-	k := utils.Sample(i, j)
-	for n := 0; n < maxIterations; n++ {
-		pos := uint(rand.Int() % b)
-		f2 := c.buckets[k].Get(pos)
-		c.buckets[k].AddInPosition(f, pos)
-		k = c.getAlternativePosition(k, f2)
-		if !c.buckets[k].isFull() {
-			c.buckets[k].Add(f2)
+	if c.eviction == BreadthFirst {
+		return c.insertBreadthFirst(i, j, f)
+	}
+	return c.insertRandomWalk(i, j, f)
+}
+
+// insertRandomWalk adds f to the full buckets i and j, evicting random fingerprints to their alternative buckets.
+// If no free slot is found after maxKicks evictions, the fingerprint left out is stashed, or the evictions are
+// rolled back when the stash is full or the fingerprint left out is f itself, so a failed insert never loses an inserted element
+func (c *CuckooFilter) insertRandomWalk(i uint, j uint, f uint64) bool {
+	c.kicks = c.kicks[:0]
+	k, inHand := utils.Sample(c.random, i, j), f
+	for n := uint(0); n < c.maxKicks; n++ {
+		pos := uint(c.random.Int63()) % c.b
+		evicted := c.bucket(k).Get(pos)
+		c.bucket(k).AddInPosition(inHand, pos)
+		c.kicks = append(c.kicks, pathStep{bucket: k, pos: pos, f: evicted})
+		k = c.getAlternativePosition(k, evicted)
+		if !c.bucket(k).isFull() {
+			c.bucket(k).Add(evicted)
 			return true
 		}
-		f2.Copy(f)
+		inHand = evicted
+	}
+	if uint(len(c.stash)) < c.stashSize && !(inHand == f && (k == i || k == j)) {
+		c.stash = append(c.stash, stashed{bucket: k, f: inHand})
+		return true
 	}
+	// Put every evicted fingerprint back in place of the one that evicted it, from the last eviction to the first
+	for n := len(c.kicks) - 1; n > 0; n-- {
+		c.bucket(c.kicks[n].bucket).replace(c.kicks[n-1].f, c.kicks[n].f)
+	}
+	c.bucket(c.kicks[0].bucket).replace(f, c.kicks[0].f)
 	return false
 }
 
-func (c CuckooFilter) computeHashPositionsAndFingerprint(element []byte) (uint, uint, *bitset.BitSet) {
-	hashed := computeHash(element, c.seed)
+func (c *CuckooFilter) computeHashPositionsAndFingerprint(element []byte) (uint, uint, uint64) {
+	hashed := c.hasher.Sum64(element, c.seed)
 	i, f := c.getPositionAndFingerprint(hashed)
 	j := c.getAlternativePosition(i, f)
 	return i, j, f
 }
 
-func computeHash(element []byte, seed uint32) uint {
-	return uint(murmur3.Sum64WithSeed(element, seed))
-}
-
-func (c CuckooFilter) getPositionAndFingerprint(hash uint) (uint, *bitset.BitSet) {
-	bitHash := bitset.From([]uint64{uint64(hash)})
-	f := bitset.New(c.p)
-	for i := uint(0); i < c.p; i++ {
-		f.SetTo(i, bitHash.Test(i))
+// getPositionAndFingerprint returns the first bucket and the fingerprint of hash. The fingerprint takes the p lowest bits
+// and the bucket the remaining ones, computed in 64 bits so they do not overlap in 32-bit machines.
+// As zero marks empty slots, a zero fingerprint becomes 1
+func (c *CuckooFilter) getPositionAndFingerprint(hash uint64) (uint, uint64) {
+	f := hash & utils.Mask(c.p)
+	if f == empty {
+		f = 1
 	}
-	i := (hash >> c.p) % c.m
+	i := uint((hash >> c.p) % uint64(c.m))
 	return i, f
 }
 
-func (c CuckooFilter) getAlternativePosition(i uint, f *bitset.BitSet) uint {
-	bitHash := make([]byte, 8)
-	binary.LittleEndian.PutUint64(bitHash, uint64(utils.BitSetToUint(f)))
-	h := computeHash(bitHash, c.seed)
-	setH := bitset.From([]uint64{uint64(h)})
-	setI := bitset.From([]uint64{uint64(i)})
-	position := bitset.New(64)
-
-	for j := uint(0); j < position.Len(); j++ {
-		bit := setH.Test(j) != setI.Test(j)
-		position.SetTo(j, bit)
-	}
+func (c *CuckooFilter) getAlternativePosition(i uint, f uint64) uint {
+	var bitHash [8]byte
+	binary.LittleEndian.PutUint64(bitHash[:], f)
+	h := c.hasher.Sum64(bitHash[:], c.seed)
+	return uint((h ^ uint64(i)) % uint64(c.m))
+}
 
-	return utils.BitSetToUint(position) % c.m
+// falsePositiveRate returns the probability that a lookup of an element not in CF finds one of the fingerprints of its 2 buckets,
+// given the load factor of CF
+func (c *CuckooFilter) falsePositiveRate(load float64) float64 {
+	return 1 - math.Pow(1-1/math.Pow(2, float64(c.p)), 2*float64(c.b)*load)
 }
 
-func (c CuckooFilter) computeError() float64 {
-	return 2 * b / (math.Pow(2, float64(c.p)))
+func (c *CuckooFilter) computeError() float64 {
+	return 2 * float64(c.b) / (math.Pow(2, float64(c.p)))
 }
 
-func computeSizeP(e float64) uint {
-	return uint(math.Ceil(math.Log2(2*b/e)))
+// computeSizeP returns the fingerprint size that keeps the error of m buckets of b fingerprints below e. Partial-key cuckoo hashing
+// only reaches the load factor of b when fingerprints have at least log2(m)/b bits, as shorter ones give too few alternative buckets
+func computeSizeP(e float64, m uint, b uint) uint {
+	p := uint(math.Ceil(math.Log2(2 * float64(b) / e)))
+	if minP := (uint(bits.TrailingZeros(m)) + b - 1) / b; minP > p {
+		p = minP
+	}
+	if p > maxP {
+		p = maxP
+	}
+	return p
 }
 
-func computeSizeM(size uint) uint {
-	return utils.NextPowerOf2(uint(math.Ceil(float64(size) / (loadFactor * b))))
+func computeSizeM(size uint, b uint) uint {
+	return utils.NextPowerOf2(uint(math.Ceil(float64(size) / (loadFactors[b] * float64(b)))))
 }
 
-func computeCapacity(m uint) uint {
-	return uint(math.Floor(float64(m) * float64(b) * loadFactor))
+func computeCapacity(m uint, b uint) uint {
+	return uint(math.Floor(float64(m) * float64(b) * loadFactors[b]))
 }
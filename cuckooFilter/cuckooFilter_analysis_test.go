@@ -1,10 +1,13 @@
 package cuckooFilter
 
 import (
+	"ProbabilisticDataStructures/filter"
 	"ProbabilisticDataStructures/utils"
 	"encoding/csv"
 	"fmt"
 	"os"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 )
@@ -15,7 +18,25 @@ type proof struct {
 	e float64
 }
 
+// bucketSizes are the bucket sizes every analysis is repeated with, writing a file per bucket size
+var bucketSizes = []uint{1, 2, 4, 8}
+
+// newWithBucketSize creates a CF of n elements with e false positive error and buckets of b fingerprints
+func newWithBucketSize(t *testing.T, n uint, e float64, b uint) *CuckooFilter {
+	f, err := NewWithOptions(n, e, WithBucketSize(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
 func TestThroughputInsert(t *testing.T) {
+	for _, b := range bucketSizes {
+		throughputInsert(t, b)
+	}
+}
+
+func throughputInsert(t *testing.T, b uint) {
 	usernames, err := utils.ReadDataset()
 	if err != nil {
 		t.Fatal(err)
@@ -37,7 +58,7 @@ func TestThroughputInsert(t *testing.T) {
 	for k, pr := range proofs {
 		results[k] = make([]int64, n)
 		for i := 0; i < arithmeticMean; i++ {
-			f := NewFromSizeAndError(n, pr.e)
+			f := newWithBucketSize(t, n, pr.e, b)
 			for j, user := range usernames {
 				start := time.Now()
 				ok := f.Insert(user)
@@ -49,7 +70,7 @@ func TestThroughputInsert(t *testing.T) {
 			}
 		}
 	}
-	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Insert_n:%d.csv", n))
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Insert_n:%d_b:%d.csv", n, b))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,6 +104,12 @@ func TestThroughputInsert(t *testing.T) {
 }
 
 func TestThroughputLookup(t *testing.T) {
+	for _, b := range bucketSizes {
+		throughputLookup(t, b)
+	}
+}
+
+func throughputLookup(t *testing.T, b uint) {
 	usernames, err := utils.ReadDataset()
 	if err != nil {
 		t.Fatal(err)
@@ -104,7 +131,7 @@ func TestThroughputLookup(t *testing.T) {
 	for k, pr := range proofs {
 		results[k] = make([]int64, n)
 		for i := 0; i < arithmeticMean; i++ {
-			f := NewFromSizeAndError(n, pr.e)
+			f := newWithBucketSize(t, n, pr.e, b)
 			for _, user := range usernames {
 				ok := f.Insert(user)
 				if !ok {
@@ -122,7 +149,7 @@ func TestThroughputLookup(t *testing.T) {
 			}
 		}
 	}
-	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Lookup_n:%d.csv", n))
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Lookup_n:%d_b:%d.csv", n, b))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,6 +183,12 @@ func TestThroughputLookup(t *testing.T) {
 }
 
 func TestThroughputDelete(t *testing.T) {
+	for _, b := range bucketSizes {
+		throughputDelete(t, b)
+	}
+}
+
+func throughputDelete(t *testing.T, b uint) {
 	usernames, err := utils.ReadDataset()
 	if err != nil {
 		t.Fatal(err)
@@ -177,7 +210,7 @@ func TestThroughputDelete(t *testing.T) {
 	for k, pr := range proofs {
 		results[k] = make([]int64, n)
 		for i := 0; i < arithmeticMean; i++ {
-			f := NewFromSizeAndError(n, pr.e)
+			f := newWithBucketSize(t, n, pr.e, b)
 			for _, user := range usernames {
 				ok := f.Insert(user)
 				if !ok {
@@ -195,7 +228,7 @@ func TestThroughputDelete(t *testing.T) {
 			}
 		}
 	}
-	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Delete_n:%d.csv", n))
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Delete_n:%d_b:%d.csv", n, b))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -230,6 +263,12 @@ func TestThroughputDelete(t *testing.T) {
 
 
 func TestFPRateWhileInserting(t *testing.T) {
+	for _, b := range bucketSizes {
+		fpRateWhileInserting(t, b)
+	}
+}
+
+func fpRateWhileInserting(t *testing.T, b uint) {
 	datasetSize := 126000
 	usernames, err := utils.ReadDatasetFromCsvAndFixLengthTo(datasetSize)
 	if err != nil {
@@ -252,17 +291,22 @@ func TestFPRateWhileInserting(t *testing.T) {
 
 	n := 110000
 	bunch := 1000
+	// Insert up to the capacity of the buckets, which is below the dataset for the smallest buckets
+	inserts := datasetSize
+	if capacity := int(computeCapacity(computeSizeM(uint(n), b), b)); capacity < inserts {
+		inserts = capacity / bunch * bunch
+	}
 	results := make([][]float64, len(proofs))
 	for k, pr := range proofs {
-		results[k] = make([]float64, datasetSize/bunch)
+		results[k] = make([]float64, inserts/bunch)
 		insertPoint := 0
-		f := NewFromSizeAndError(uint(n), pr.e)
-		for insertPoint < datasetSize {
+		f := newWithBucketSize(t, uint(n), pr.e, b)
+		for insertPoint < inserts {
 			aux := 0
-			for aux < bunch && insertPoint < datasetSize {
+			for aux < bunch && insertPoint < inserts {
 				ok := f.Insert(usernames[insertPoint])
 				if !ok {
-					t.Fatalf("insertion has fail in insertion %d, when load factor is %f", insertPoint, float64(insertPoint)/float64(f.m * b))
+					t.Fatalf("insertion has fail in insertion %d, when load factor is %f", insertPoint, float64(insertPoint)/float64(f.m * f.b))
 				}
 				insertPoint++
 				aux++
@@ -280,7 +324,7 @@ func TestFPRateWhileInserting(t *testing.T) {
 			}
 		}
 	}
-	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_FP_n:%d_bunch:%d_1.csv", n, bunch))
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_FP_n:%d_bunch:%d_b:%d_1.csv", n, bunch, b))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -314,6 +358,12 @@ func TestFPRateWhileInserting(t *testing.T) {
 }
 
 func TestFPRateWhenFilterIsAtMaxAllowedCapacity(t *testing.T) {
+	for _, b := range bucketSizes {
+		fpRateWhenFilterIsAtMaxAllowedCapacity(t, b)
+	}
+}
+
+func fpRateWhenFilterIsAtMaxAllowedCapacity(t *testing.T, b uint) {
 	datasetSize := 110000
 	usernames, err := utils.ReadDatasetFromCsvAndFixLengthTo(datasetSize)
 	if err != nil {
@@ -337,12 +387,12 @@ func TestFPRateWhenFilterIsAtMaxAllowedCapacity(t *testing.T) {
 	lenP := 20
 	for k, pr := range proofs {
 		results[k] = make([]float64, lenP)
-		f := NewFromSizeAndError(uint(datasetSize), pr.e)
+		f := newWithBucketSize(t, uint(datasetSize), pr.e, b)
 		fmt.Println(f.m, f.p)
 		for i, user := range usernames {
 			ok := f.Insert(user)
 			if !ok {
-				t.Fatalf("insertion has fail in insertion %d, when load factor is %f", i, float64(i)/float64(f.m * b))
+				t.Fatalf("insertion has fail in insertion %d, when load factor is %f", i, float64(i)/float64(f.m * f.b))
 			}
 		}
 		for j := 0; j < arithmeticMean; j++ {
@@ -359,7 +409,7 @@ func TestFPRateWhenFilterIsAtMaxAllowedCapacity(t *testing.T) {
 			}
 		}
 	}
-	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_FP_n:%d_2.csv", datasetSize))
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_FP_n:%d_b:%d_2.csv", datasetSize, b))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -484,13 +534,19 @@ var proofs = []proof{
 }
 
 func TestSizeInFunctionOfErrorRate(t *testing.T) {
+	for _, b := range bucketSizes {
+		sizeInFunctionOfErrorRate(t, b)
+	}
+}
+
+func sizeInFunctionOfErrorRate(t *testing.T, b uint) {
 	n := 150000
 	results := make([][]string, len(proofs))
 	for k, pr := range proofs {
-		f := NewFromSizeAndError(uint(n), pr.e)
+		f := newWithBucketSize(t, uint(n), pr.e, b)
 		results[k] = []string{fmt.Sprint(pr.e), fmt.Sprint(f.TotalSize())}
 	}
-	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_size_n:%d.csv", n))
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_size_n:%d_b:%d.csv", n, b))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -513,20 +569,40 @@ func TestSizeInFunctionOfErrorRate(t *testing.T) {
 }
 
 func TestBitsPerSlotInFunctionOfErrorRate(t *testing.T) {
+	for _, b := range bucketSizes {
+		bitsPerSlotInFunctionOfErrorRate(t, b)
+	}
+}
+
+func bitsPerSlotInFunctionOfErrorRate(t *testing.T, b uint) {
 	n := 41943040
 	results := make([][]string, len(proofs))
+	// Semi-sorting only applies to buckets of 4 fingerprints, which also report the bits per slot and the fraction of memory it saves
+	semiSorting := b == semiSortedBucketSize
 	for k, pr := range proofs {
-		f := NewFromSizeAndError(uint(n), pr.e)
+		f := newWithBucketSize(t, uint(n), pr.e, b)
 		results[k] = []string{fmt.Sprint(pr.e), fmt.Sprint(f.p)}
+		if semiSorting {
+			semiSorted, err := NewWithOptions(uint(n), pr.e, WithBucketSize(b), WithSemiSorting())
+			if err != nil {
+				t.Fatal(err)
+			}
+			saving := 1 - float64(semiSorted.TotalSize())/float64(f.TotalSize())
+			results[k] = append(results[k], fmt.Sprint(semiSorted.table.Width()), fmt.Sprint(saving))
+		}
 	}
-	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_bits_per_element_n:%d.csv", n))
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_bits_per_element_n:%d_b:%d.csv", n, b))
 	if err != nil {
 		t.Fatal(err)
 	}
 	w := csv.NewWriter(resultsFile)
 
 	//Title
-	err = w.Write([]string{"error", "size"})
+	title := []string{"error", "size"}
+	if semiSorting {
+		title = append(title, "semi-sorted size", "saving")
+	}
+	err = w.Write(title)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -539,4 +615,134 @@ func TestBitsPerSlotInFunctionOfErrorRate(t *testing.T) {
 	}
 	w.Flush()
 	resultsFile.Close()
-}
\ No newline at end of file
+}
+func TestThroughputConcurrentInsertAndLookup(t *testing.T) {
+	usernames, err := utils.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := uint(len(usernames))
+	e := 0.001
+	var results [][]string
+	for goroutines := 1; goroutines <= 2*runtime.GOMAXPROCS(0); goroutines *= 2 {
+		var locked, striped int64
+		for i := 0; i < arithmeticMean; i++ {
+			c := NewFromSizeAndError(n, e)
+			locked += insertAndLookupInParallel(t, NewConcurrent(&c), usernames, goroutines)
+			c = NewFromSizeAndError(n, e)
+			striped += insertAndLookupInParallel(t, NewStriped(&c), usernames, goroutines)
+		}
+		results = append(results, []string{fmt.Sprint(goroutines), fmt.Sprint(locked / arithmeticMean), fmt.Sprint(striped / arithmeticMean)})
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Concurrent_InsertAndLookup_n:%d.csv", n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"goroutines", "rwmutex", "striped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+func TestThroughputInsertByEviction(t *testing.T) {
+	usernames, err := utils.ReadDataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := 0.001
+	strategies := []EvictionStrategy{RandomWalk, BreadthFirst}
+	var results [][]string
+	for _, b := range bucketSizes {
+		// Fill the largest table whose capacity fits in the dataset up to its capacity, where most inserts need evictions
+		m := computeSizeM(uint(len(usernames)), b)
+		if computeCapacity(m, b) > uint(len(usernames)) {
+			m /= 2
+		}
+		n := computeCapacity(m, b)
+		row := []string{fmt.Sprint(b)}
+		var moves []string
+		for _, eviction := range strategies {
+			var elapsed, moved int64
+			for i := 0; i < arithmeticMean; i++ {
+				f, err := NewWithSize(m, WithBucketSize(b), WithFingerprintBits(computeSizeP(e, m, b)), WithEviction(eviction))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, user := range usernames[:n] {
+					f.kicks = f.kicks[:0]
+					start := time.Now()
+					ok := f.Insert(user)
+					elapsed += time.Since(start).Nanoseconds()
+					if !ok {
+						t.Fatal("Insertion has fail")
+					}
+					moved += int64(len(f.kicks))
+				}
+			}
+			row = append(row, fmt.Sprint(elapsed/arithmeticMean/int64(n)))
+			moves = append(moves, fmt.Sprint(float64(moved)/float64(arithmeticMean)/float64(n)))
+		}
+		results = append(results, append(row, moves...))
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/CF_Eviction_Insert_n:%d.csv", len(usernames)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	title := []string{"b"}
+	for _, eviction := range strategies {
+		title = append(title, fmt.Sprintf("%v ns/insert", eviction))
+	}
+	for _, eviction := range strategies {
+		title = append(title, fmt.Sprintf("%v moves/insert", eviction))
+	}
+	err = w.Write(title)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+// insertAndLookupInParallel inserts and then looks up elements in f splitting them between goroutines and returns the elapsed nanoseconds
+func insertAndLookupInParallel(t *testing.T, f filter.Filter, elements [][]byte, goroutines int) int64 {
+	var wg sync.WaitGroup
+	start := time.Now()
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(elements); i += goroutines {
+				f.Insert(elements[i])
+			}
+			for i := g; i < len(elements); i += goroutines {
+				if ok := f.Lookup(elements[i]); !ok {
+					t.Errorf("%s should be in.", elements[i])
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	return time.Since(start).Nanoseconds()
+}
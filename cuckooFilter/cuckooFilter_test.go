@@ -1,16 +1,28 @@
 package cuckooFilter
 
 import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/filter/conformance"
+	"ProbabilisticDataStructures/hasher"
 	"ProbabilisticDataStructures/utils"
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
+	"math/bits"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
 const errorRangeFalsePositives = 0.1
 
 func TestComputeHashPositionsAndFingerprint(t *testing.T) {
-	for p := uint(4); p <= utils.Machine64Bits; p++ {
+	for p := uint(1); p <= maxP; p++ {
 		c := NewFromSize(1000, p)
 		i, j, f := c.computeHashPositionsAndFingerprint([]byte("Hello World"))
 		if alt := c.getAlternativePosition(i, f); alt != j {
@@ -163,3 +175,948 @@ func TestInsertAndLookupAndDeleteWithHighLoadFactor(t *testing.T) {
 	}
 
 }
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		f := NewFromSizeAndError(n, e)
+		return &f
+	})
+}
+
+func TestConcurrentConformance(t *testing.T) {
+	conformance.RunConcurrent(t, func(n uint, e float64) filter.Filter {
+		f := NewFromSizeAndError(n, e)
+		c := NewConcurrent(&f)
+		c.SetParallelism(4)
+		return c
+	})
+}
+
+func TestMarshalAndUnmarshalBinary(t *testing.T) {
+	c := NewFromSizeAndError(1000, 0.01, defaultP, 7)
+	for i := 0; i < 1000; i++ {
+		c.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	c.Delete([]byte("0"))
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CuckooFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.n != c.n || decoded.m != c.m || decoded.p != c.p || decoded.e != c.e || decoded.seed != c.seed || decoded.count != c.count {
+		t.Errorf("Expected parameters (%d, %d, %d, %f, %d, %d), Current parameters (%d, %d, %d, %f, %d, %d)", c.n, c.m, c.p, c.e, c.seed, c.count, decoded.n, decoded.m, decoded.p, decoded.e, decoded.seed, decoded.count)
+	}
+	if e := decoded.Descriptor().E; e != 0.01 {
+		t.Errorf("Expected target error %f, Current target error %f", 0.01, e)
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if c.Lookup(elem) != decoded.Lookup(elem) {
+			t.Errorf("Lookup of %s differs after decoding", elem)
+		}
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != ErrInvalidEncoding {
+		t.Errorf("Expected error %v, Current error %v", ErrInvalidEncoding, err)
+	}
+}
+
+func TestWriteToAndReadFrom(t *testing.T) {
+	c := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		c.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	var buf bytes.Buffer
+	written, err := c.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CuckooFilter
+	read, err := decoded.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != read {
+		t.Errorf("Expected %d bytes read, Current %d bytes read", written, read)
+	}
+	for i := 0; i < 1000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if ok := decoded.Lookup(elem); !ok {
+			t.Errorf("%s should be in.", elem)
+		}
+		if ok := decoded.Delete(elem); !ok {
+			t.Errorf("%s should be deleted.", elem)
+		}
+	}
+}
+
+func TestTotalSizeMatchesPackedTable(t *testing.T) {
+	for p := uint(4); p <= 32; p++ {
+		c := NewFromSize(1000, p)
+		expected := (c.m*c.b*p + utils.Machine64Bits - 1) / utils.Machine64Bits * utils.WordSize
+		if size := c.TotalSize(); size != expected {
+			t.Errorf("Expected size %d (in p = %d), Current size %d", expected, p, size)
+		}
+	}
+}
+
+func TestWriteAndReadWithEveryHasher(t *testing.T) {
+	for _, h := range []hasher.Hasher{hasher.Murmur3{}, hasher.XXHash{}, hasher.FNV1a{}, hasher.NewSipHash([16]byte{1, 2, 3, 4})} {
+		f, err := NewWithOptions(1000, 0.01, WithHasher(h), WithSeed(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 1000; i++ {
+			f.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		var buf bytes.Buffer
+		if _, err := container.Write(&buf, f); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := container.Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if algorithm := stored.Descriptor().Hash; algorithm != container.HashAlgorithm(hasher.AlgorithmOf(h)) {
+			t.Errorf("Expected hash algorithm %v, Current hash algorithm %v", hasher.AlgorithmOf(h), algorithm)
+		}
+		decoded := stored.(*CuckooFilter)
+		if decoded.hasher != h {
+			t.Errorf("Expected hasher %v, Current hasher %v", h, decoded.hasher)
+		}
+		for i := 0; i < 2000; i++ {
+			elem := []byte(fmt.Sprintf("%d", i))
+			if f.Lookup(elem) != decoded.Lookup(elem) {
+				t.Errorf("%v: lookup of %s differs after decoding", hasher.AlgorithmOf(h), elem)
+			}
+		}
+	}
+}
+
+func TestOpenMmap(t *testing.T) {
+	c := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		c.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	path := filepath.Join(t.TempDir(), "cf")
+	if err := container.Save(path, &c); err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := OpenMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+	if mapped.Descriptor() != c.Descriptor() {
+		t.Errorf("Expected descriptor %+v, Current descriptor %+v", c.Descriptor(), mapped.Descriptor())
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if c.Lookup(elem) != mapped.Lookup(elem) {
+			t.Errorf("Lookup of %s differs in the mapped filter", elem)
+		}
+	}
+	if ok := mapped.Insert([]byte("A")); ok {
+		t.Errorf("A mapped filter should NOT accept inserts")
+	}
+}
+
+func TestInsertAndLookupWithEveryFingerprintSize(t *testing.T) {
+	size := uint(1000)
+	for p := uint(1); p <= maxP; p++ {
+		c := NewFromSize(size, p)
+		for i := uint(0); i < size; i++ {
+			if ok := c.Insert([]byte(fmt.Sprintf("%d", i))); !ok && p > 2 {
+				t.Fatalf("%d NOT correctly inserted (in p = %d).", i, p)
+			}
+		}
+		for i := uint(0); i < size; i++ {
+			if ok := c.Lookup([]byte(fmt.Sprintf("%d", i))); !ok && p > 2 {
+				t.Errorf("%d should be in (in p = %d).", i, p)
+			}
+		}
+		if p < 4 {
+			continue
+		}
+		elementsToTest := 20 * size
+		falsePositives := 0
+		for i := size; i < size+elementsToTest; i++ {
+			if ok := c.Lookup([]byte(fmt.Sprintf("%d", i))); ok {
+				falsePositives++
+			}
+		}
+		maxFalsePositives := int(2*float64(elementsToTest)*c.computeError()) + 1
+		if falsePositives > maxFalsePositives {
+			t.Errorf("Error: Expected at most %d false positives (in p = %d) and current false positives are %d", maxFalsePositives, p, falsePositives)
+		}
+	}
+}
+
+func TestInsertAndLookupWithHighPrecision(t *testing.T) {
+	size := uint(100000)
+	expectedError := 0.0001
+	c := NewFromSizeAndError(size, expectedError)
+	if c.p <= 8 {
+		t.Errorf("Expected fingerprint wider than 8 bits, Current fingerprint size %d", c.p)
+	}
+	for i := uint(0); i < size; i++ {
+		if ok := c.Insert([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Fatalf("%d NOT correctly inserted.", i)
+		}
+	}
+	for i := uint(0); i < size; i++ {
+		if ok := c.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+	elementsToTest := 1000000
+	falsePositives := 0
+	for i := uint(0); i < uint(elementsToTest); i++ {
+		if ok := c.Lookup([]byte(fmt.Sprintf("%d", i+size))); ok {
+			falsePositives++
+		}
+	}
+	expectedFalsePositives := int(float64(elementsToTest) * expectedError)
+	rangeFalsePositives := int(math.Ceil(float64(expectedFalsePositives)*errorRangeFalsePositives) + 1)
+	if falsePositives-expectedFalsePositives > rangeFalsePositives {
+		t.Errorf("Error: Expected false positives are %d ± %d and current false positives are %d", expectedFalsePositives, rangeFalsePositives, falsePositives)
+	}
+}
+
+func TestItPanicsOnUnsupportedFingerprintSize(t *testing.T) {
+	for _, p := range []uint{0, maxP + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Fingerprint size %d should NOT be accepted", p)
+				}
+			}()
+			NewFromSize(1000, p)
+		}()
+	}
+}
+
+// customHasher is a Hasher other than the built-in ones
+type customHasher struct {
+	hasher.FNV1a
+}
+
+func TestNewWithOptionsRejectsInvalidParameters(t *testing.T) {
+	cases := []struct {
+		n    uint
+		e    float64
+		opts []Option
+	}{
+		{0, 0.01, nil},
+		{1000, 0, nil},
+		{1000, 1, nil},
+		{1000, math.NaN(), nil},
+		{1000, 0.01, []Option{WithFingerprintBits(0)}},
+		{1000, 0.01, []Option{WithFingerprintBits(maxP + 1)}},
+		{1000, 0.01, []Option{WithBucketSize(3)}},
+		{1000, 0.01, []Option{WithMaxKicks(0)}},
+		{1000, 0.01, []Option{WithHasher(nil)}},
+		{1000, 0.01, []Option{WithStashSize(maxStashSize + 1)}},
+		{1000, 0.01, []Option{WithEviction(BreadthFirst + 1)}},
+		{1000, 0.01, []Option{WithRandSource(nil)}},
+		{1000, 0.01, []Option{WithSemiSorting(), WithBucketSize(8)}},
+		{1000, 0.01, []Option{WithSemiSorting(), WithFingerprintBits(3)}},
+	}
+	for i, c := range cases {
+		if _, err := NewWithOptions(c.n, c.e, c.opts...); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("Case %d (n = %d and e = %v) should return an invalid parameter error, got %v", i, c.n, c.e, err)
+		}
+	}
+	for _, m := range []uint{0, 3, 1000} {
+		if _, err := NewWithSize(m); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("m = %d should return an invalid parameter error, got %v", m, err)
+		}
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	size := uint(10000)
+	c, err := NewWithOptions(size, 0.01, WithFingerprintBits(12), WithBucketSize(8), WithMaxKicks(100), WithSeed(42), WithEviction(BreadthFirst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.p != 12 || c.b != 8 || c.maxKicks != 100 || c.seed != 42 {
+		t.Errorf("Expected p = 12, b = 8, maxKicks = 100 and seed = 42, Current p = %d, b = %d, maxKicks = %d and seed = %d", c.p, c.b, c.maxKicks, c.seed)
+	}
+	if expectedM := computeSizeM(size, 8); c.m != expectedM {
+		t.Errorf("Expected m = %d, Current m = %d", expectedM, c.m)
+	}
+	for i := uint(0); i < size; i++ {
+		if ok := c.Insert([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Fatalf("%d NOT correctly inserted.", i)
+		}
+	}
+	for i := uint(0); i < size; i++ {
+		if ok := c.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CuckooFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.b != c.b || decoded.maxKicks != c.maxKicks || decoded.seed != c.seed || decoded.eviction != c.eviction {
+		t.Errorf("Decoded CF should keep its options")
+	}
+	for i := uint(0); i < size; i++ {
+		if ok := decoded.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Errorf("%d should be in the decoded CF.", i)
+		}
+	}
+}
+
+func TestNewWithHasher(t *testing.T) {
+	c, err := NewWithOptions(1000, 0.01, WithHasher(customHasher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		element := []byte(fmt.Sprintf("%d", i))
+		if !c.Insert(element) || !c.Lookup(element) {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+	if _, err := c.MarshalBinary(); !errors.Is(err, ErrUnsupportedHasher) {
+		t.Errorf("Expected %v encoding a CF with a custom hasher, got %v", ErrUnsupportedHasher, err)
+	}
+}
+
+func TestStripedConformance(t *testing.T) {
+	conformance.RunConcurrent(t, func(n uint, e float64) filter.Filter {
+		f := NewFromSizeAndError(n, e)
+		s := NewStriped(&f)
+		s.SetParallelism(4)
+		return s
+	})
+}
+
+func TestStripesCoverWholeWords(t *testing.T) {
+	for _, b := range []uint{1, 2, 4, 8} {
+		for p := uint(1); p <= maxP; p++ {
+			for _, m := range []uint{2, 1 << 10, 1 << 16} {
+				c, err := NewWithSize(m, WithBucketSize(b), WithFingerprintBits(p))
+				if err != nil {
+					t.Fatal(err)
+				}
+				s := NewStriped(c)
+				if s.bucketsPerStripe*b*p%utils.Machine64Bits != 0 {
+					t.Errorf("m = %d, b = %d and p = %d: a stripe of %d buckets does not cover whole words", m, b, p, s.bucketsPerStripe)
+				}
+				if uint(len(s.stripes)) > defaultStripes || s.stripe(m-1) >= uint(len(s.stripes)) {
+					t.Errorf("m = %d, b = %d and p = %d: %d stripes of %d buckets do not cover the table", m, b, p, len(s.stripes), s.bucketsPerStripe)
+				}
+			}
+		}
+	}
+}
+
+func TestStripedStressKeepsEveryFingerprint(t *testing.T) {
+	for _, eviction := range []EvictionStrategy{RandomWalk, BreadthFirst} {
+		t.Run(eviction.String(), func(t *testing.T) {
+			const writers = 8
+			const readers = 4
+			c, err := NewWithOptions(100000, 0.001, WithBucketSize(4), WithEviction(eviction))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := NewStriped(c)
+			// Fill up to 90% of the slots, so most inserts need evictions
+			size := uint(float64(c.m*c.b) * 0.9)
+			inserted := make([]int32, size)
+			var wg, readersWg sync.WaitGroup
+			done := make(chan struct{})
+			for r := 0; r < readers; r++ {
+				readersWg.Add(1)
+				go func(r int) {
+					defer readersWg.Done()
+					rng := rand.New(rand.NewSource(int64(r)))
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						i := uint(rng.Intn(int(size)))
+						if atomic.LoadInt32(&inserted[i]) == 1 && !s.Lookup([]byte(fmt.Sprintf("%d", i))) {
+							t.Errorf("%d was lost while other elements were inserted.", i)
+						}
+					}
+				}(r)
+			}
+			for w := uint(0); w < writers; w++ {
+				wg.Add(1)
+				go func(w uint) {
+					defer wg.Done()
+					for i := w; i < size; i += writers {
+						if s.Insert([]byte(fmt.Sprintf("%d", i))) {
+							atomic.StoreInt32(&inserted[i], 1)
+						}
+					}
+				}(w)
+			}
+			wg.Wait()
+			close(done)
+			readersWg.Wait()
+			insertedCount := 0
+			for i := uint(0); i < size; i++ {
+				if inserted[i] == 0 {
+					continue
+				}
+				insertedCount++
+				if ok := s.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+					t.Errorf("%d should be in.", i)
+				}
+			}
+			if float64(insertedCount) < 0.99*float64(size) {
+				t.Errorf("Expected at least 99%% of %d elements inserted, Current inserted %d", size, insertedCount)
+			}
+			occupied := 0
+			for k := uint(0); k < c.m*c.b; k++ {
+				if c.table.Get(k) != empty {
+					occupied++
+				}
+			}
+			if occupied != insertedCount || s.count != int64(insertedCount) {
+				t.Errorf("Expected %d fingerprints, Current occupied slots %d and count %d", insertedCount, occupied, s.count)
+			}
+		})
+	}
+}
+
+func TestInsertBatchReportsFailedInserts(t *testing.T) {
+	c, err := NewWithSize(16, WithMaxKicks(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := make([][]byte, 200)
+	for i := range batch {
+		batch[i] = []byte(fmt.Sprintf("%d", i))
+	}
+	err = c.InsertBatch(batch)
+	var batchErr *filter.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Inserting more elements than slots should return a *filter.BatchError, got %v", err)
+	}
+	if batchErr.Total != len(batch) || c.count != uint(len(batch)-len(batchErr.Failed)) {
+		t.Errorf("Expected %d inserted elements, Current count %d and %d failed of %d", len(batch)-len(batchErr.Failed), c.count, len(batchErr.Failed), batchErr.Total)
+	}
+}
+
+func TestCountFollowsInsertsAndDeletes(t *testing.T) {
+	c := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		c.Insert([]byte(fmt.Sprint(i)))
+	}
+	for i := 0; i < 300; i++ {
+		c.Delete([]byte(fmt.Sprint(i)))
+	}
+	if err := c.DeleteBatch(elementsOf(300, 500)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Delete([]byte("never inserted")) {
+		t.Fatalf("never inserted should not be deleted")
+	}
+	if stats := c.Stats(); stats.Count != 500 || stats.LoadFactor != 500/float64(c.m*c.b) || stats.TargetError != 0.01 {
+		t.Errorf("Expected 500 elements in %d slots with target error 0.01, got %+v", c.m*c.b, stats)
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CuckooFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Stats() != c.Stats() {
+		t.Errorf("Expected stats %+v after decoding, got %+v", c.Stats(), decoded.Stats())
+	}
+}
+
+func elementsOf(start int, end int) [][]byte {
+	elements := make([][]byte, 0, end-start)
+	for i := start; i < end; i++ {
+		elements = append(elements, []byte(fmt.Sprint(i)))
+	}
+	return elements
+}
+
+func TestFailedInsertsNeverDropElements(t *testing.T) {
+	for _, stashSize := range []uint{0, defaultStashSize} {
+		c, err := NewWithSize(64, WithMaxKicks(20), WithStashSize(stashSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var inserted [][]byte
+		for _, elem := range elementsOf(0, int(2*c.m*c.b)) {
+			if c.Insert(elem) {
+				inserted = append(inserted, elem)
+			}
+		}
+		if uint(len(inserted)) == 2*c.m*c.b || uint(len(c.stash)) > stashSize || c.count != uint(len(inserted)) {
+			t.Fatalf("Expected some of %d inserts to fail and at most %d stashed, Current %d inserted, %d stashed and count %d",
+				2*c.m*c.b, stashSize, len(inserted), len(c.stash), c.count)
+		}
+		for _, elem := range inserted {
+			if ok := c.Lookup(elem); !ok {
+				t.Errorf("%s should be in with stash size %d.", elem, stashSize)
+			}
+		}
+		for _, elem := range inserted {
+			if ok := c.Delete(elem); !ok {
+				t.Errorf("%s should be deleted with stash size %d.", elem, stashSize)
+			}
+		}
+		if c.count != 0 || len(c.stash) != 0 {
+			t.Errorf("Expected an empty filter, Current count %d and %d stashed", c.count, len(c.stash))
+		}
+	}
+}
+
+func TestStashedElementsSurviveEncodingAndStriping(t *testing.T) {
+	c, err := NewWithSize(64, WithMaxKicks(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inserted [][]byte
+	for _, elem := range elementsOf(0, int(2*c.m*c.b)) {
+		if c.Insert(elem) {
+			inserted = append(inserted, elem)
+		}
+	}
+	if len(c.stash) == 0 {
+		t.Fatal("Expected failed inserts to fill the stash")
+	}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CuckooFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.stashSize != c.stashSize || len(decoded.stash) != len(c.stash) {
+		t.Errorf("Expected %d of %d stashed, Current %d of %d", len(c.stash), c.stashSize, len(decoded.stash), decoded.stashSize)
+	}
+	s := NewStriped(&decoded)
+	for _, elem := range inserted {
+		if ok := s.Lookup(elem); !ok {
+			t.Errorf("%s should be in.", elem)
+		}
+		if ok := s.Delete(elem); !ok {
+			t.Errorf("%s should be deleted.", elem)
+		}
+	}
+	if len(decoded.stash) != 0 {
+		t.Errorf("Expected an empty stash, Current %d stashed", len(decoded.stash))
+	}
+}
+
+func TestStripedMarshalWhileDeletingStashed(t *testing.T) {
+	c, err := NewWithSize(64, WithMaxKicks(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inserted [][]byte
+	for _, elem := range elementsOf(0, int(2*c.m*c.b)) {
+		if c.Insert(elem) {
+			inserted = append(inserted, elem)
+		}
+	}
+	if len(c.stash) == 0 {
+		t.Fatal("Expected failed inserts to fill the stash")
+	}
+	s := NewStriped(c)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, elem := range inserted {
+			s.Delete(elem)
+		}
+	}()
+	for deleting := true; deleting; {
+		select {
+		case <-done:
+			deleting = false
+		default:
+		}
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded CuckooFilter
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.stash) != 0 || s.Stats().Count != 0 {
+		t.Errorf("Expected an empty filter, Current %d stashed of %d elements", len(c.stash), s.Stats().Count)
+	}
+}
+
+func TestStripedMarshalWhileInsertingIntoAFullTable(t *testing.T) {
+	c, err := NewWithSize(256, WithMaxKicks(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, elem := range elementsOf(0, int(c.m*c.b*9/10)) {
+		c.Insert(elem)
+	}
+	s := NewStriped(c)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			// Inserts into a near-full table evict fingerprints, drawing pseudo-random numbers
+			for _, elem := range elementsOf(int(c.m*c.b)*(g+1), int(c.m*c.b)*(g+1)+200) {
+				s.Insert(elem)
+			}
+		}(g)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for inserting := true; inserting; {
+		select {
+		case <-done:
+			inserting = false
+		default:
+		}
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded CuckooFilter
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConformanceWithEveryBucketSize(t *testing.T) {
+	for b := range loadFactors {
+		t.Run(fmt.Sprintf("b=%d", b), func(t *testing.T) {
+			conformance.Run(t, func(n uint, e float64) filter.Filter {
+				f, err := NewWithOptions(n, e, WithBucketSize(b))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return f
+			})
+		})
+	}
+}
+
+func TestFillsEveryBucketSizeUpToItsLoadFactor(t *testing.T) {
+	for b, loadFactor := range loadFactors {
+		c, err := NewWithOptions(100000, 0.01, WithBucketSize(b), WithStashSize(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.computeError() > 0.01 || c.p < uint(bits.TrailingZeros(c.m))/b {
+			t.Errorf("b = %d: Expected error below 0.01 and at least %d bits per fingerprint, Current error %v and p = %d", b, bits.TrailingZeros(c.m)/int(b), c.computeError(), c.p)
+		}
+		capacity := computeCapacity(c.m, b)
+		if capacity < c.n || float64(capacity) > loadFactor*float64(c.m*b) {
+			t.Errorf("b = %d: Expected capacity between %d and %v, Current capacity %d", b, c.n, loadFactor*float64(c.m*b), capacity)
+		}
+		for i, elem := range elementsOf(0, int(capacity)) {
+			if ok := c.Insert(elem); !ok {
+				t.Fatalf("b = %d: %s NOT correctly inserted at load factor %v.", b, elem, float64(i)/float64(c.m*b))
+			}
+		}
+	}
+}
+
+func TestBreadthFirstConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		f, err := NewWithOptions(n, e, WithEviction(BreadthFirst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	})
+	conformance.RunConcurrent(t, func(n uint, e float64) filter.Filter {
+		f, err := NewWithOptions(n, e, WithEviction(BreadthFirst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewStriped(f)
+	})
+}
+
+func TestBreadthFirstMovesFewerFingerprints(t *testing.T) {
+	moves := make(map[EvictionStrategy]int)
+	for _, eviction := range []EvictionStrategy{RandomWalk, BreadthFirst} {
+		c, err := NewWithSize(1<<12, WithFingerprintBits(16), WithStashSize(0), WithEviction(eviction))
+		if err != nil {
+			t.Fatal(err)
+		}
+		elements := elementsOf(0, int(computeCapacity(c.m, c.b)))
+		for _, elem := range elements {
+			c.kicks = c.kicks[:0]
+			if ok := c.Insert(elem); !ok {
+				t.Fatalf("%v: %s NOT correctly inserted.", eviction, elem)
+			}
+			moves[eviction] += len(c.kicks)
+		}
+		for _, elem := range elements {
+			if ok := c.Lookup(elem); !ok {
+				t.Errorf("%v: %s should be in.", eviction, elem)
+			}
+		}
+	}
+	if moves[BreadthFirst] >= moves[RandomWalk] {
+		t.Errorf("Expected breadth-first search to move fewer fingerprints than %d, Current %d", moves[RandomWalk], moves[BreadthFirst])
+	}
+}
+
+func TestBreadthFirstFailureLeavesTableUntouched(t *testing.T) {
+	c, err := NewWithSize(16, WithEviction(BreadthFirst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elem := []byte("Hello World")
+	for i := uint(0); i < 2*c.b; i++ {
+		if ok := c.Insert(elem); !ok {
+			t.Fatalf("%s NOT correctly inserted in.", elem)
+		}
+	}
+	before := append([]uint64(nil), c.table.Words()...)
+	if ok := c.Insert(elem); ok {
+		t.Fatalf("%s should NOT be correctly inserted in.", elem)
+	}
+	for w, word := range c.table.Words() {
+		if word != before[w] {
+			t.Fatalf("A failed insert should not modify the table, word %d changed", w)
+		}
+	}
+}
+
+func TestSameInsertsBuildTheSameTable(t *testing.T) {
+	build := func(randSeed uint64, elements [][]byte) *CuckooFilter {
+		c, err := NewWithSize(64, WithMaxKicks(50), WithRandSeed(randSeed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, elem := range elements {
+			c.Insert(elem)
+		}
+		return c
+	}
+	encode := func(c *CuckooFilter) []byte {
+		data, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	// Filling past capacity makes inserts evict and fail
+	first, rest := elementsOf(0, 200), elementsOf(200, 300)
+	a, b := build(7, first), build(7, first)
+	if !bytes.Equal(encode(a), encode(b)) {
+		t.Fatalf("The same inserts with the same seed should build the same CF")
+	}
+	if bytes.Equal(encode(a), encode(build(8, first))) {
+		t.Errorf("Different seeds should evict different fingerprints")
+	}
+	// A decoded CF continues the sequence of pseudo-random numbers of the encoded one
+	var decoded CuckooFilter
+	if err := decoded.UnmarshalBinary(encode(a)); err != nil {
+		t.Fatal(err)
+	}
+	for _, elem := range rest {
+		if a.Insert(elem) != decoded.Insert(elem) {
+			t.Errorf("Insert of %s differs after decoding", elem)
+		}
+	}
+	if !bytes.Equal(encode(a), encode(&decoded)) {
+		t.Errorf("The decoded CF should evict the same fingerprints")
+	}
+	c, err := NewWithSize(64, WithRandSource(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.MarshalBinary(); err != ErrUnsupportedRandSource {
+		t.Errorf("Expected error %v, Current error %v", ErrUnsupportedRandSource, err)
+	}
+}
+
+func TestSemiSortedBucketsKeepTheirFingerprints(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, p := range []uint{4, 5, 8, 13, maxP} {
+		c, err := NewWithSize(16, WithFingerprintBits(p), WithSemiSorting())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if width := c.table.Width(); width != p-1 {
+			t.Errorf("p = %d: Expected slots of %d bits, Current %d bits", p, p-1, width)
+		}
+		for i := 0; i < 1000; i++ {
+			var fingerprints [semiSortedBucketSize]uint64
+			for pos := range fingerprints {
+				// Leave some slots empty
+				if rng.Intn(4) > 0 {
+					fingerprints[pos] = uint64(rng.Int63()) & utils.Mask(p)
+				}
+			}
+			k := uint(rng.Intn(int(c.m)))
+			c.bucket(k).store(fingerprints)
+			loaded := c.bucket(k).load()
+			for _, f := range fingerprints {
+				if ok, _ := c.bucket(k).isElement(f); !ok {
+					t.Fatalf("p = %d: %#x should be in bucket %d holding %#x", p, f, k, loaded)
+				}
+			}
+			for pos := 1; pos < len(loaded); pos++ {
+				if loaded[pos] < loaded[pos-1] {
+					t.Fatalf("p = %d: Expected sorted fingerprints, Current %#x", p, loaded)
+				}
+			}
+		}
+	}
+}
+
+func TestSemiSortedConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		f, err := NewWithOptions(n, e, WithSemiSorting())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	})
+	conformance.RunConcurrent(t, func(n uint, e float64) filter.Filter {
+		f, err := NewWithOptions(n, e, WithSemiSorting(), WithEviction(BreadthFirst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewStriped(f)
+	})
+}
+
+func TestSemiSortingSavesABitPerFingerprint(t *testing.T) {
+	n, e := uint(100000), 0.01
+	falsePositives := make(map[bool]int)
+	for _, semiSorted := range []bool{false, true} {
+		opts := []Option{WithStashSize(0)}
+		if semiSorted {
+			opts = append(opts, WithSemiSorting())
+		}
+		c, err := NewWithOptions(n, e, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := utils.PackedWordsNeeded(c.m*c.b, slotBits(c.p, semiSorted)) * utils.WordSize; c.TotalSize() != expected {
+			t.Errorf("semi-sorted %t: Expected %d bytes, Current %d bytes", semiSorted, expected, c.TotalSize())
+		}
+		elements := elementsOf(0, int(computeCapacity(c.m, c.b)))
+		for _, elem := range elements {
+			if ok := c.Insert(elem); !ok {
+				t.Fatalf("semi-sorted %t: %s NOT correctly inserted.", semiSorted, elem)
+			}
+		}
+		data, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded CuckooFilter
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "cf")
+		if err := container.Save(path, c); err != nil {
+			t.Fatal(err)
+		}
+		mapped, err := OpenMmap(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, elem := range elements {
+			if ok := decoded.Lookup(elem); !ok {
+				t.Errorf("semi-sorted %t: %s should be in.", semiSorted, elem)
+			}
+			if ok := mapped.Lookup(elem); !ok {
+				t.Errorf("semi-sorted %t: %s should be in the mapped filter.", semiSorted, elem)
+			}
+		}
+		mapped.Close()
+		for _, elem := range elementsOf(len(elements), len(elements)+100000) {
+			if c.Lookup(elem) {
+				falsePositives[semiSorted]++
+			}
+		}
+		for _, elem := range elements {
+			if ok := c.Delete(elem); !ok {
+				t.Errorf("semi-sorted %t: %s should be deleted.", semiSorted, elem)
+			}
+		}
+		if c.count != 0 || c.table.Words()[0] != 0 {
+			t.Errorf("semi-sorted %t: Expected an empty table after deleting every element", semiSorted)
+		}
+	}
+	// Both keep the same fingerprints, so their false positives only differ by chance
+	if math.Abs(float64(falsePositives[true]-falsePositives[false])) > 4*math.Sqrt(float64(falsePositives[false])) {
+		t.Errorf("Expected around %d false positives when semi-sorted, Current %d", falsePositives[false], falsePositives[true])
+	}
+}
+
+func TestSemiSortedFailedInsertsNeverDropElements(t *testing.T) {
+	for _, eviction := range []EvictionStrategy{RandomWalk, BreadthFirst} {
+		c, err := NewWithSize(64, WithMaxKicks(20), WithStashSize(0), WithEviction(eviction), WithSemiSorting())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var inserted [][]byte
+		for _, elem := range elementsOf(0, int(2*c.m*c.b)) {
+			if c.Insert(elem) {
+				inserted = append(inserted, elem)
+			}
+		}
+		for _, elem := range inserted {
+			if ok := c.Lookup(elem); !ok {
+				t.Errorf("%v: %s should be in.", eviction, elem)
+			}
+		}
+		if c.count != uint(len(inserted)) {
+			t.Errorf("%v: Expected count %d, Current count %d", eviction, len(inserted), c.count)
+		}
+	}
+}
+
+func TestDecodingRejectsUntrustedSizes(t *testing.T) {
+	c := NewFromSizeAndError(1000, 0.01)
+	valid, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// m and the number of words of the table, which must neither overflow nor be allocated before reading them
+	cases := [][2]uint64{
+		{1 << 63, 0},
+		{1 << 58, uint64(utils.PackedWordsNeeded(1<<58*c.b, c.p))},
+	}
+	for _, s := range cases {
+		data := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint64(data[2*utils.WordSize:], s[0])
+		binary.LittleEndian.PutUint64(data[tableWordsIndex*utils.WordSize:], s[1])
+		var decoded CuckooFilter
+		if err := decoded.UnmarshalBinary(data); err != ErrInvalidEncoding {
+			t.Errorf("m=%d: Expected error %v, Current error %v", s[0], ErrInvalidEncoding, err)
+		}
+		if _, err := decoded.ReadFrom(bytes.NewReader(data)); err == nil {
+			t.Errorf("m=%d: Reading a corrupted encoding should fail", s[0])
+		}
+	}
+}
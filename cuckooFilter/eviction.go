@@ -0,0 +1,114 @@
+package cuckooFilter
+
+import "fmt"
+
+// EvictionStrategy is how an insert whose two buckets are full makes room for its fingerprint
+type EvictionStrategy uint8
+
+const (
+	// RandomWalk evicts a random fingerprint of a full bucket to its alternative bucket, repeating with the evicted one
+	// until it finds a free slot or makes maxKicks evictions
+	RandomWalk EvictionStrategy = iota
+	// BreadthFirst searches the shortest path of evictions that ends in a free slot, examining at most maxKicks slots,
+	// and only then moves its fingerprints. A failed search leaves the table untouched
+	BreadthFirst
+)
+
+func (s EvictionStrategy) String() string {
+	switch s {
+	case RandomWalk:
+		return "random-walk"
+	case BreadthFirst:
+		return "breadth-first"
+	default:
+		return fmt.Sprintf("EvictionStrategy(%d)", uint8(s))
+	}
+}
+
+// searchNode is a bucket reached by the breadth-first search, moving the fingerprint f in slot pos of the bucket of node parent.
+// The buckets where the search starts have no parent
+type searchNode struct {
+	bucket uint
+	parent int
+	pos    uint
+	f      uint64
+}
+
+// breadthFirstPath searches the shortest eviction path starting at bucket i or j whose last fingerprint can be moved to a free slot,
+// reading the table with slot and isFull. It returns the path from its first eviction to its last, appended to path,
+// and the nodes of the search, which can be reused for the next one. No bucket appears twice in the path
+func (c *CuckooFilter) breadthFirstPath(i uint, j uint, nodes []searchNode, path []pathStep,
+	slot func(k uint, pos uint) uint64, isFull func(k uint) bool) ([]searchNode, []pathStep, bool) {
+	nodes = append(nodes[:0], searchNode{bucket: i, parent: -1})
+	if j != i {
+		nodes = append(nodes, searchNode{bucket: j, parent: -1})
+	}
+	examined := uint(0)
+	for head := 0; head < len(nodes); head++ {
+		k := nodes[head].bucket
+		for pos := uint(0); pos < c.b; pos++ {
+			if examined == c.maxKicks {
+				return nodes, path, false
+			}
+			examined++
+			f := slot(k, pos)
+			to := c.getAlternativePosition(k, f)
+			if onPath(nodes, head, to) {
+				continue
+			}
+			nodes = append(nodes, searchNode{bucket: to, parent: head, pos: pos, f: f})
+			if !isFull(to) {
+				return nodes, appendPath(path, nodes, len(nodes)-1), true
+			}
+		}
+	}
+	return nodes, path, false
+}
+
+// onPath returns true if bucket k is in the path from a starting bucket to node
+func onPath(nodes []searchNode, node int, k uint) bool {
+	for ; node >= 0; node = nodes[node].parent {
+		if nodes[node].bucket == k {
+			return true
+		}
+	}
+	return false
+}
+
+// appendPath appends to path the evictions that lead from a starting bucket to node, the first one first
+func appendPath(path []pathStep, nodes []searchNode, node int) []pathStep {
+	start := len(path)
+	for ; nodes[node].parent >= 0; node = nodes[node].parent {
+		path = append(path, pathStep{bucket: nodes[nodes[node].parent].bucket, pos: nodes[node].pos, f: nodes[node].f})
+	}
+	for a, b := start, len(path)-1; a < b; a, b = a+1, b-1 {
+		path[a], path[b] = path[b], path[a]
+	}
+	return path
+}
+
+// insertBreadthFirst adds f to bucket i or j after moving the fingerprints of the shortest eviction path, which is searched
+// before modifying the table. Returns false, leaving the table untouched, if no path is found
+func (c *CuckooFilter) insertBreadthFirst(i uint, j uint, f uint64) bool {
+	var ok bool
+	c.nodes, c.kicks, ok = c.breadthFirstPath(i, j, c.nodes, c.kicks[:0], c.slot, c.isFull)
+	if !ok {
+		return false
+	}
+	// Move the fingerprints backwards, from the one with a free slot, so each one moves to the slot freed by the next
+	for n := len(c.kicks) - 1; n >= 0; n-- {
+		step := c.kicks[n]
+		c.bucket(c.getAlternativePosition(step.bucket, step.f)).Add(step.f)
+		c.bucket(step.bucket).deletePos(step.pos)
+	}
+	c.bucket(c.kicks[0].bucket).Add(f)
+	return true
+}
+
+func (c *CuckooFilter) slot(k uint, pos uint) uint64 {
+	return c.bucket(k).Get(pos)
+}
+
+func (c *CuckooFilter) isFull(k uint) bool {
+	return c.bucket(k).isFull()
+}
@@ -0,0 +1,97 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"errors"
+)
+
+var _ filter.Filter = (*MmapCuckooFilter)(nil)
+
+// ErrWrongKind is returned when opening a container that does not store a CF
+var ErrWrongKind = errors.New("cuckooFilter: container does not store a Cuckoo Filter")
+
+// MmapCuckooFilter is a read-only Cuckoo Filter that answers lookups directly against a memory-mapped container
+type MmapCuckooFilter struct {
+	filter CuckooFilter
+	data   []byte
+}
+
+// OpenMmap maps the container at path, written with container.Save, without copying its table into the heap.
+// The mapping is shared, so processes opening the same file share its pages. Close must be called to release it
+func OpenMmap(path string) (*MmapCuckooFilter, error) {
+	data, err := utils.Mmap(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := mapCF(data)
+	if err != nil {
+		utils.Munmap(data)
+		return nil, err
+	}
+	return &MmapCuckooFilter{filter: c, data: data}, nil
+}
+
+// Insert always returns false, as a memory-mapped CF is read-only
+func (c *MmapCuckooFilter) Insert(element []byte) bool {
+	return false
+}
+
+// Lookup returns true if element may belong to the CF and false if element does not belong to the CF. Computational time: O(1)
+func (c *MmapCuckooFilter) Lookup(element []byte) bool {
+	return c.filter.Lookup(element)
+}
+
+// TotalSize returns an estimation (in bytes) of the size of the array that represents CF.
+func (c *MmapCuckooFilter) TotalSize() uint {
+	return c.filter.TotalSize()
+}
+
+// Descriptor returns the description of CF written in the header of its container
+func (c *MmapCuckooFilter) Descriptor() container.Descriptor {
+	return c.filter.Descriptor()
+}
+
+// Close unmaps the CF. It must not be used afterwards
+func (c *MmapCuckooFilter) Close() error {
+	data := c.data
+	c.data = nil
+	c.filter.table = utils.PackedArray{}
+	return utils.Munmap(data)
+}
+
+func mapCF(data []byte) (CuckooFilter, error) {
+	h, payload, err := container.Split(data)
+	if err != nil {
+		return CuckooFilter{}, err
+	}
+	if h.Kind != container.KindCuckoo {
+		return CuckooFilter{}, ErrWrongKind
+	}
+	header, words, err := utils.ReadWords(payload, headerWords)
+	if err != nil {
+		return CuckooFilter{}, ErrInvalidEncoding
+	}
+	c, err := decodeHeader(header)
+	if err != nil {
+		return CuckooFilter{}, err
+	}
+	tableBytes := header[tableWordsIndex] * utils.WordSize
+	if uint64(len(words)) < tableBytes {
+		return CuckooFilter{}, ErrInvalidEncoding
+	}
+	// Only the table is mapped, the few stashed fingerprints are copied
+	if c.stash, err = decodeStash(words[tableBytes:], header[12], c); err != nil {
+		return CuckooFilter{}, err
+	}
+	table, err := utils.AliasWords(words[:tableBytes])
+	if err != nil {
+		return CuckooFilter{}, err
+	}
+	c.table = utils.PackedArrayFrom(table, slotBits(c.p, c.semiSorted))
+	if c.Descriptor() != h.Descriptor {
+		return CuckooFilter{}, container.ErrDescriptorMismatch
+	}
+	return c, nil
+}
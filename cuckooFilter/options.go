@@ -0,0 +1,169 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"fmt"
+	"math/rand"
+)
+
+// Option configures a CF created with NewWithOptions or NewWithSize
+type Option func(*config) error
+
+type config struct {
+	// p is 0 when the fingerprint size has to be computed from the error
+	p          uint
+	b          uint
+	maxKicks   uint
+	seed       uint32
+	hasher     hasher.Hasher
+	stashSize  uint
+	eviction   EvictionStrategy
+	random     rand.Source
+	semiSorted bool
+}
+
+func newConfig(opts []Option) (config, error) {
+	cfg := config{
+		b:         defaultBucketSize,
+		maxKicks:  defaultMaxKicks,
+		seed:      defaultSeed,
+		hasher:    hasher.Murmur3{},
+		stashSize: defaultStashSize,
+	}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, err
+		}
+	}
+	if cfg.random == nil {
+		cfg.random = utils.NewSplitMix64(defaultRandSeed)
+	}
+	return cfg, nil
+}
+
+// legacyOptions translates the variadic parameters of New and NewFromSize: op[0] is the fingerprint size and op[1] the seed
+func legacyOptions(op []uint) []Option {
+	var opts []Option
+	if len(op) > 0 {
+		opts = append(opts, WithFingerprintBits(op[0]))
+	}
+	if len(op) > 1 {
+		opts = append(opts, WithSeed(uint32(op[1])))
+	}
+	return opts
+}
+
+// WithFingerprintBits sets the fingerprint size p, between 1 and 32 bits
+func WithFingerprintBits(p uint) Option {
+	return func(c *config) error {
+		if p == 0 || p > maxP {
+			return parameterError("p", p, fmt.Sprintf("fingerprint size must be between 1 and %d bits", maxP))
+		}
+		c.p = p
+		return nil
+	}
+}
+
+// WithSeed sets the seed of the hash function. The default seed is 1
+func WithSeed(seed uint32) Option {
+	return func(c *config) error {
+		c.seed = seed
+		return nil
+	}
+}
+
+// WithHasher sets the hash function used to compute buckets and fingerprints. The default is hasher.Murmur3. Only the built-in hashers can be serialized
+func WithHasher(h hasher.Hasher) Option {
+	return func(c *config) error {
+		if h == nil {
+			return parameterError("hasher", h, "must not be nil")
+		}
+		c.hasher = h
+		return nil
+	}
+}
+
+// WithBucketSize sets the number of fingerprints per bucket b: 1, 2, 4 or 8. The default is 4
+func WithBucketSize(b uint) Option {
+	return func(c *config) error {
+		if _, ok := loadFactors[b]; !ok {
+			return parameterError("b", b, "bucket size must be 1, 2, 4 or 8")
+		}
+		c.b = b
+		return nil
+	}
+}
+
+// WithMaxKicks sets the maximum number of evictions before an insert fails. The default is 500
+func WithMaxKicks(maxKicks uint) Option {
+	return func(c *config) error {
+		if maxKicks == 0 {
+			return parameterError("maxKicks", maxKicks, "must be greater than 0")
+		}
+		c.maxKicks = maxKicks
+		return nil
+	}
+}
+
+// WithStashSize sets the number of fingerprints evicted by failed inserts that are kept aside, up to 64. The default is 4.
+// Once the stash is full, failed inserts roll back their evictions instead
+func WithStashSize(size uint) Option {
+	return func(c *config) error {
+		if size > maxStashSize {
+			return parameterError("stashSize", size, fmt.Sprintf("stash size must be at most %d", maxStashSize))
+		}
+		c.stashSize = size
+		return nil
+	}
+}
+
+// WithEviction sets how inserts make room when both buckets of an element are full. The default is RandomWalk
+func WithEviction(strategy EvictionStrategy) Option {
+	return func(c *config) error {
+		if strategy != RandomWalk && strategy != BreadthFirst {
+			return parameterError("eviction", strategy, "must be RandomWalk or BreadthFirst")
+		}
+		c.eviction = strategy
+		return nil
+	}
+}
+
+// WithRandSeed seeds the pseudo-random numbers that choose the fingerprints evicted by random walks. The default seed is 1
+func WithRandSeed(seed uint64) Option {
+	return func(c *config) error {
+		c.random = utils.NewSplitMix64(seed)
+		return nil
+	}
+}
+
+// WithRandSource sets the source of the pseudo-random numbers that choose the fingerprints evicted by random walks.
+// Only a *utils.SplitMix64 can be serialized, as WithRandSeed creates
+func WithRandSource(source rand.Source) Option {
+	return func(c *config) error {
+		if source == nil {
+			return parameterError("random", source, "must not be nil")
+		}
+		c.random = source
+		return nil
+	}
+}
+
+// WithSemiSorting stores the fingerprints of every bucket sorted, encoding their 4 high bits together so each one takes a bit less
+// with the same false positive rate. It needs buckets of 4 fingerprints of at least 4 bits, and makes operations slower
+func WithSemiSorting() Option {
+	return func(c *config) error {
+		c.semiSorted = true
+		return nil
+	}
+}
+
+func parameterError(parameter string, value interface{}, reason string) error {
+	return &filter.ParameterError{
+		Filter:    "cuckooFilter",
+		Parameter: parameter,
+		Value:     value,
+		Reason:    reason,
+	}
+}
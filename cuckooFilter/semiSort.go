@@ -0,0 +1,85 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/utils"
+	"sync"
+)
+
+// Semi-sorting (Fan et al.) stores the 4 fingerprints of a bucket sorted, so the 4 high bits of each of them form one of the
+// 3876 sorted sequences of 4 nibbles, whose 12-bit index replaces their 16 bits. Every slot keeps the p-4 low bits of its
+// fingerprint and 3 bits of the index, saving a bit per fingerprint with the same false positive rate
+const (
+	semiSortedBucketSize = uint(4)
+	// minSemiSortedP is the smallest fingerprint holding a whole nibble
+	minSemiSortedP = nibbleBits
+	nibbleBits     = uint(4)
+	// indexBitsPerSlot are the bits of the index of the sorted nibbles of a bucket kept in each of its slots
+	indexBitsPerSlot = uint(3)
+)
+
+var (
+	semiSortOnce sync.Once
+	// sortedNibbles are the sorted sequences of 4 nibbles, the first one in the lowest bits, by index
+	sortedNibbles []uint16
+	// nibblesIndex is the index of every sorted sequence of 4 nibbles
+	nibblesIndex []uint16
+)
+
+func initSemiSort() {
+	semiSortOnce.Do(func() {
+		nibblesIndex = make([]uint16, 1<<(semiSortedBucketSize*nibbleBits))
+		for a := uint16(0); a < 16; a++ {
+			for b := a; b < 16; b++ {
+				for c := b; c < 16; c++ {
+					for d := c; d < 16; d++ {
+						nibbles := a | b<<4 | c<<8 | d<<12
+						nibblesIndex[nibbles] = uint16(len(sortedNibbles))
+						sortedNibbles = append(sortedNibbles, nibbles)
+					}
+				}
+			}
+		}
+	})
+}
+
+// slotBits returns the width of the slots holding fingerprints of p bits
+func slotBits(p uint, semiSorted bool) uint {
+	if semiSorted {
+		return p - 1
+	}
+	return p
+}
+
+// load returns the fingerprints of a semi-sorted bucket, in increasing order
+func (b bucket) load() [semiSortedBucketSize]uint64 {
+	var fingerprints [semiSortedBucketSize]uint64
+	index := uint64(0)
+	for pos := uint(0); pos < semiSortedBucketSize; pos++ {
+		slot := b.table.Get(b.offset + pos)
+		index |= slot >> b.lowBits << (indexBitsPerSlot * pos)
+		fingerprints[pos] = slot & utils.Mask(b.lowBits)
+	}
+	nibbles := uint64(sortedNibbles[index])
+	for pos := uint(0); pos < semiSortedBucketSize; pos++ {
+		fingerprints[pos] |= (nibbles >> (nibbleBits * pos) & 0xF) << b.lowBits
+	}
+	return fingerprints
+}
+
+// store sorts fingerprints and stores them in a semi-sorted bucket
+func (b bucket) store(fingerprints [semiSortedBucketSize]uint64) {
+	for i := 1; i < len(fingerprints); i++ {
+		for j := i; j > 0 && fingerprints[j] < fingerprints[j-1]; j-- {
+			fingerprints[j], fingerprints[j-1] = fingerprints[j-1], fingerprints[j]
+		}
+	}
+	nibbles := uint16(0)
+	for pos := uint(0); pos < semiSortedBucketSize; pos++ {
+		nibbles |= uint16(fingerprints[pos]>>b.lowBits) << (nibbleBits * pos)
+	}
+	index := uint64(nibblesIndex[nibbles])
+	for pos := uint(0); pos < semiSortedBucketSize; pos++ {
+		slot := (index>>(indexBitsPerSlot*pos))&utils.Mask(indexBitsPerSlot)<<b.lowBits | fingerprints[pos]&utils.Mask(b.lowBits)
+		b.table.Set(b.offset+pos, slot)
+	}
+}
@@ -0,0 +1,172 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"encoding"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	encodingVersion = uint64(9)
+	// version, n, m, p, b, maxKicks, seed, hash algorithm, the two words of its key, count, stash size, number of stashed
+	// fingerprints, eviction strategy, state of the pseudo-random numbers, semi-sorting, e and number of words of the table
+	headerWords = 18
+	// tableWordsIndex is the index in the header of the number of words of the table
+	tableWordsIndex = 17
+	// stashedWords is the number of words of a stashed fingerprint: its bucket and itself
+	stashedWords = 2
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*CuckooFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*CuckooFilter)(nil)
+	_ io.WriterTo                = (*CuckooFilter)(nil)
+	_ io.ReaderFrom              = (*CuckooFilter)(nil)
+)
+
+var (
+	// ErrInvalidEncoding is returned when decoding data that does not represent a CF
+	ErrInvalidEncoding = errors.New("cuckooFilter: invalid binary encoding")
+	// ErrUnsupportedVersion is returned when decoding data encoded with an unknown version
+	ErrUnsupportedVersion = errors.New("cuckooFilter: unsupported encoding version")
+	// ErrUnsupportedHasher is returned when encoding a CF whose hash function is not one of the built-in hashers
+	ErrUnsupportedHasher = errors.New("cuckooFilter: only the built-in hashers can be encoded")
+	// ErrUnsupportedRandSource is returned when encoding a CF whose source of pseudo-random numbers is not a *utils.SplitMix64
+	ErrUnsupportedRandSource = errors.New("cuckooFilter: only a *utils.SplitMix64 source of pseudo-random numbers can be encoded")
+)
+
+// MarshalBinary encodes CF as a little-endian sequence of 64-bit words: the header followed by the packed table and the stash.
+// The header keeps the state of the pseudo-random numbers, so the decoded CF evicts the same fingerprints CF would
+func (c *CuckooFilter) MarshalBinary() ([]byte, error) {
+	algorithm, key, err := hasher.Encode(c.hasher)
+	if err != nil {
+		return nil, ErrUnsupportedHasher
+	}
+	random, ok := c.random.(*utils.SplitMix64)
+	if !ok {
+		return nil, ErrUnsupportedRandSource
+	}
+	words := c.table.Words()
+	data := make([]byte, 0, (headerWords+len(words)+stashedWords*len(c.stash))*utils.WordSize)
+	data = utils.AppendWords(data, encodingVersion, uint64(c.n), uint64(c.m), uint64(c.p), uint64(c.b), uint64(c.maxKicks), uint64(c.seed), uint64(algorithm), key[0], key[1],
+		uint64(c.count), uint64(c.stashSize), uint64(len(c.stash)), uint64(c.eviction), random.State(), boolToWord(c.semiSorted), math.Float64bits(c.e), uint64(len(words)))
+	data = utils.AppendWords(data, words...)
+	for _, e := range c.stash {
+		data = utils.AppendWords(data, uint64(e.bucket), e.f)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a CF previously encoded with MarshalBinary, replacing the content of c
+func (c *CuckooFilter) UnmarshalBinary(data []byte) error {
+	header, data, err := utils.ReadWords(data, headerWords)
+	if err != nil {
+		return ErrInvalidEncoding
+	}
+	filter, err := decodeHeader(header)
+	if err != nil {
+		return err
+	}
+	words, data, err := utils.ReadWords(data, header[tableWordsIndex])
+	if err != nil {
+		return ErrInvalidEncoding
+	}
+	if filter.stash, err = decodeStash(data, header[12], filter); err != nil {
+		return err
+	}
+	filter.table = utils.PackedArrayFrom(words, slotBits(filter.p, filter.semiSorted))
+	*c = filter
+	return nil
+}
+
+// WriteTo writes the binary encoding of CF to w
+func (c *CuckooFilter) WriteTo(w io.Writer) (int64, error) {
+	data, err := c.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadFrom reads a binary encoding of a CF from r, replacing the content of c
+func (c *CuckooFilter) ReadFrom(r io.Reader) (int64, error) {
+	data, n, err := utils.ReadFullWords(r, nil, headerWords)
+	if err != nil {
+		return n, err
+	}
+	header, _, _ := utils.ReadWords(data, headerWords)
+	if _, err := decodeHeader(header); err != nil {
+		return n, err
+	}
+	data, read, err := utils.ReadFullWords(r, data, header[tableWordsIndex]+stashedWords*header[12])
+	n += read
+	if err != nil {
+		return n, err
+	}
+	return n, c.UnmarshalBinary(data)
+}
+
+// decodeHeader returns the CF described by header, without its table
+func decodeHeader(header []uint64) (CuckooFilter, error) {
+	if header[0] != encodingVersion {
+		return CuckooFilter{}, ErrUnsupportedVersion
+	}
+	m, p, b, semiSorted, e := uint(header[2]), uint(header[3]), uint(header[4]), header[15] == 1, math.Float64frombits(header[16])
+	_, validB := loadFactors[b]
+	if m == 0 || !(e > 0) || math.IsInf(e, 1) || m&(m-1) != 0 || p == 0 || p > maxP || !validB || header[5] == 0 || header[6] > math.MaxUint32 || header[7] > math.MaxUint8 ||
+		header[11] > uint64(maxStashSize) || header[12] > header[11] || header[13] > uint64(BreadthFirst) || header[15] > 1 ||
+		semiSorted && (b != semiSortedBucketSize || p < minSemiSortedP) || !utils.PackedArrayFits(m, b*slotBits(p, semiSorted)) ||
+		header[tableWordsIndex] != uint64(utils.PackedWordsNeeded(m*b, slotBits(p, semiSorted))) {
+		return CuckooFilter{}, ErrInvalidEncoding
+	}
+	if semiSorted {
+		initSemiSort()
+	}
+	h, err := hasher.Decode(hasher.Algorithm(header[7]), [2]uint64{header[8], header[9]})
+	if err != nil {
+		return CuckooFilter{}, ErrInvalidEncoding
+	}
+	return CuckooFilter{
+		n:          uint(header[1]),
+		m:          m,
+		p:          p,
+		b:          b,
+		e:          e,
+		maxKicks:   uint(header[5]),
+		seed:       uint32(header[6]),
+		count:      uint(header[10]),
+		hasher:     h,
+		stashSize:  uint(header[11]),
+		eviction:   EvictionStrategy(header[13]),
+		random:     utils.NewSplitMix64(header[14]),
+		semiSorted: semiSorted,
+	}, nil
+}
+
+func boolToWord(value bool) uint64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// decodeStash decodes the stashLen stashed fingerprints of c encoded in data, which must hold nothing else
+func decodeStash(data []byte, stashLen uint64, c CuckooFilter) ([]stashed, error) {
+	words, data, err := utils.ReadWords(data, stashedWords*stashLen)
+	if err != nil || len(data) != 0 {
+		return nil, ErrInvalidEncoding
+	}
+	stash := make([]stashed, 0, c.stashSize)
+	for s := 0; s < len(words); s += stashedWords {
+		bucket, f := words[s], words[s+1]
+		if bucket >= uint64(c.m) || f == 0 || f > utils.Mask(c.p) {
+			return nil, ErrInvalidEncoding
+		}
+		stash = append(stash, stashed{bucket: uint(bucket), f: f})
+	}
+	return stash, nil
+}
@@ -0,0 +1,48 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"sync/atomic"
+)
+
+var (
+	_ filter.StatsReporter = (*CuckooFilter)(nil)
+	_ filter.StatsReporter = (*ConcurrentCuckooFilter)(nil)
+	_ filter.StatsReporter = (*StripedCuckooFilter)(nil)
+	_ filter.StatsReporter = (*MmapCuckooFilter)(nil)
+)
+
+// Stats returns the statistics of CF, whose count is exact. Computational time: O(1)
+func (c *CuckooFilter) Stats() filter.Stats {
+	return c.stats(c.count)
+}
+
+// Stats returns the statistics of CF, holding the read lock. Computational time: O(1)
+func (c *ConcurrentCuckooFilter) Stats() filter.Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.Stats()
+}
+
+// Stats returns the statistics of CF, whose count includes the inserts and deletes completed so far. Computational time: O(1)
+func (s *StripedCuckooFilter) Stats() filter.Stats {
+	return s.filter.stats(uint(atomic.LoadInt64(&s.count)))
+}
+
+// Stats returns the statistics of CF, whose count is the one it was saved with. Computational time: O(1)
+func (c *MmapCuckooFilter) Stats() filter.Stats {
+	return c.filter.Stats()
+}
+
+// stats returns the statistics of CF holding count fingerprints
+func (c *CuckooFilter) stats(count uint) filter.Stats {
+	load := float64(count) / float64(c.m*c.b)
+	return filter.Stats{
+		Count:             count,
+		LoadFactor:        load,
+		Capacity:          c.n,
+		TargetError:       c.e,
+		FalsePositiveRate: c.falsePositiveRate(load),
+		MemoryBytes:       c.TotalSize(),
+	}
+}
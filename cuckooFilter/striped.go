@@ -0,0 +1,364 @@
+package cuckooFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// defaultStripes is the maximum number of locks of a StripedCuckooFilter
+	defaultStripes = uint(1024)
+	// maxPathRetries is the number of times an insert searches a new eviction path when another writer invalidates it
+	maxPathRetries = 8
+)
+
+var (
+	_ filter.DeletableFilter = (*StripedCuckooFilter)(nil)
+	_ filter.UniqueInserter  = (*StripedCuckooFilter)(nil)
+	_ filter.BatchFilter     = (*StripedCuckooFilter)(nil)
+	_ filter.BatchDeleter    = (*StripedCuckooFilter)(nil)
+	_ container.Storable     = (*StripedCuckooFilter)(nil)
+)
+
+// StripedCuckooFilter is a Cuckoo Filter safe for concurrent use by multiple goroutines. Its buckets are split into
+// ranges (stripes) guarded by their own lock, so writers and readers of different stripes proceed in parallel.
+// Every stripe covers whole words of the packed table, so no word is shared between two locks
+type StripedCuckooFilter struct {
+	filter *CuckooFilter
+	// stripes guard ranges of bucketsPerStripe consecutive buckets
+	stripes          []sync.RWMutex
+	bucketsPerStripe uint
+	// relocations counts the fingerprints moved between buckets, so lookups detect they raced with an eviction
+	relocations uint64
+	count       int64
+	// randomMu guards the source of pseudo-random numbers of filter
+	randomMu sync.Mutex
+	// stashMu guards the stash of filter. Its stashed fingerprints can be looked up and deleted, but failed inserts never stash new ones
+	stashMu sync.RWMutex
+	// parallelism is the number of goroutines the batch operations fan out to
+	parallelism int
+}
+
+// NewStriped wraps c so it can be shared between goroutines. c must not be used directly afterwards
+func NewStriped(c *CuckooFilter) *StripedCuckooFilter {
+	// The fewest buckets whose slots fill whole words, a power of 2 as m is
+	aligned := utils.Machine64Bits / gcd(c.b*slotBits(c.p, c.semiSorted), utils.Machine64Bits)
+	bucketsPerStripe := aligned
+	if perStripe := c.m / defaultStripes; perStripe > bucketsPerStripe {
+		bucketsPerStripe = perStripe
+	}
+	stripes := (c.m + bucketsPerStripe - 1) / bucketsPerStripe
+	return &StripedCuckooFilter{
+		filter:           c,
+		stripes:          make([]sync.RWMutex, stripes),
+		bucketsPerStripe: bucketsPerStripe,
+		count:            int64(c.count),
+	}
+}
+
+// SetParallelism sets the number of goroutines the batch operations split their elements between. By default they do not fan out.
+// It must be called before sharing the filter
+func (s *StripedCuckooFilter) SetParallelism(goroutines int) {
+	s.parallelism = goroutines
+}
+
+// Insert inserts element into CF. Returns true if element has been inserted, false otherwise. Amortized computational time: O(1)
+func (s *StripedCuckooFilter) Insert(element []byte) bool {
+	i, j, f := s.filter.computeHashPositionsAndFingerprint(element)
+	if s.insert(i, j, f) {
+		atomic.AddInt64(&s.count, 1)
+		return true
+	}
+	return false
+}
+
+// InsertUnique inserts element into CF if element is not already inserted. Returns true if element has been inserted or already exists, false otherwise.
+// Concurrent InsertUnique calls of the same element may both insert it when its two buckets are full. Amortized computational time: O(1)
+func (s *StripedCuckooFilter) InsertUnique(element []byte) bool {
+	if s.Lookup(element) {
+		return true
+	}
+	return s.Insert(element)
+}
+
+// Lookup returns true if element may belong to the CF and false if element does not belong to the CF.
+// It checks each bucket holding only its lock, and checks both again holding their two locks if a fingerprint was relocated meanwhile.
+// Computational time: O(1)
+func (s *StripedCuckooFilter) Lookup(element []byte) bool {
+	i, j, f := s.filter.computeHashPositionsAndFingerprint(element)
+	return s.lookup(i, j, f)
+}
+
+// Delete deletes element in the filter. Returns true if element has been deleted, false otherwise. Computational time: O(1)
+func (s *StripedCuckooFilter) Delete(element []byte) bool {
+	i, j, f := s.filter.computeHashPositionsAndFingerprint(element)
+	return s.delete(i, j, f)
+}
+
+func (s *StripedCuckooFilter) lookup(i uint, j uint, f uint64) bool {
+	relocations := atomic.LoadUint64(&s.relocations)
+	if s.contains(i, f) || s.contains(j, f) || s.isStashed(i, j, f) {
+		return true
+	}
+	if atomic.LoadUint64(&s.relocations) == relocations {
+		return false
+	}
+	s.rlockPair(i, j)
+	defer s.runlockPair(i, j)
+	ok, _ := s.filter.bucket(i).isElement(f)
+	if !ok {
+		ok, _ = s.filter.bucket(j).isElement(f)
+	}
+	return ok || s.isStashed(i, j, f)
+}
+
+func (s *StripedCuckooFilter) isStashed(i uint, j uint, f uint64) bool {
+	s.stashMu.RLock()
+	defer s.stashMu.RUnlock()
+	return s.filter.stashed(i, j, f) >= 0
+}
+
+func (s *StripedCuckooFilter) delete(i uint, j uint, f uint64) bool {
+	s.lockPair(i, j)
+	defer s.unlockPair(i, j)
+	for _, k := range [2]uint{i, j} {
+		if ok, pos := s.filter.bucket(k).isElement(f); ok {
+			s.filter.bucket(k).deletePos(pos)
+			atomic.AddInt64(&s.count, -1)
+			return true
+		}
+	}
+	s.stashMu.Lock()
+	defer s.stashMu.Unlock()
+	if st := s.filter.stashed(i, j, f); st >= 0 {
+		s.filter.removeStashed(st)
+		atomic.AddInt64(&s.count, -1)
+		return true
+	}
+	return false
+}
+
+// InsertBatch inserts elements into CF, splitting them between the goroutines set by SetParallelism.
+// Returns a *filter.BatchError holding the elements that could not be inserted, nil if all of them have been inserted
+func (s *StripedCuckooFilter) InsertBatch(elements [][]byte) error {
+	ok := make([]bool, len(elements))
+	s.forEachSortedElement(elements, func(start int, e hashedElement) {
+		if ok[start+e.index] = s.insert(e.i, e.j, e.f); ok[start+e.index] {
+			atomic.AddInt64(&s.count, 1)
+		}
+	})
+	return filter.BatchResult("cuckooFilter: insert", ok)
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in CF, splitting the elements between the goroutines set by SetParallelism
+func (s *StripedCuckooFilter) LookupBatch(elements [][]byte, results []bool) {
+	s.forEachSortedElement(elements, func(start int, e hashedElement) {
+		results[start+e.index] = s.lookup(e.i, e.j, e.f)
+	})
+}
+
+// DeleteBatch deletes elements in CF, splitting them between the goroutines set by SetParallelism.
+// Returns a *filter.BatchError holding the elements that were not found, nil if all of them have been deleted
+func (s *StripedCuckooFilter) DeleteBatch(elements [][]byte) error {
+	ok := make([]bool, len(elements))
+	s.forEachSortedElement(elements, func(start int, e hashedElement) {
+		ok[start+e.index] = s.delete(e.i, e.j, e.f)
+	})
+	return filter.BatchResult("cuckooFilter: delete", ok)
+}
+
+// TotalSize returns the size (in bytes) of the packed table that represents CF, that is m*b*p bits (m*b*(p-1) when semi-sorted) rounded up to words.
+func (s *StripedCuckooFilter) TotalSize() uint {
+	return s.filter.TotalSize()
+}
+
+// Descriptor returns the description of CF written in the header of a container
+func (s *StripedCuckooFilter) Descriptor() container.Descriptor {
+	return s.filter.Descriptor()
+}
+
+// MarshalBinary encodes a snapshot of CF, with the same encoding as CuckooFilter.MarshalBinary. It holds every lock while encoding
+func (s *StripedCuckooFilter) MarshalBinary() ([]byte, error) {
+	for i := range s.stripes {
+		s.stripes[i].Lock()
+	}
+	defer func() {
+		for i := range s.stripes {
+			s.stripes[i].Unlock()
+		}
+	}()
+	s.stashMu.RLock()
+	defer s.stashMu.RUnlock()
+	s.randomMu.Lock()
+	defer s.randomMu.Unlock()
+	s.filter.count = uint(atomic.LoadInt64(&s.count))
+	return s.filter.MarshalBinary()
+}
+
+// forEachSortedElement splits elements between the goroutines set by SetParallelism, calling fn with every element
+// of a chunk starting at start grouped by its first bucket
+func (s *StripedCuckooFilter) forEachSortedElement(elements [][]byte, fn func(start int, e hashedElement)) {
+	utils.ParallelChunks(len(elements), s.parallelism, func(start int, end int) {
+		s.filter.forEachSortedElement(elements[start:end], func(e hashedElement) {
+			fn(start, e)
+		})
+	})
+}
+
+func (s *StripedCuckooFilter) insert(i uint, j uint, f uint64) bool {
+	for retry := 0; retry < maxPathRetries; retry++ {
+		if s.addToFreeSlot(i, j, f) {
+			return true
+		}
+		path, ok := s.findPath(i, j)
+		if !ok {
+			return false
+		}
+		// Move the fingerprints backwards, from the one with a free slot, so none of them is ever out of the table
+		for n := len(path) - 1; n >= 0 && ok; n-- {
+			ok = s.relocate(path[n])
+		}
+	}
+	return false
+}
+
+// addToFreeSlot adds f to bucket i or j if any of them has a free slot
+func (s *StripedCuckooFilter) addToFreeSlot(i uint, j uint, f uint64) bool {
+	s.lockPair(i, j)
+	defer s.unlockPair(i, j)
+	for _, k := range [2]uint{i, j} {
+		if !s.filter.bucket(k).isFull() {
+			s.filter.bucket(k).Add(f)
+			return true
+		}
+	}
+	return false
+}
+
+// findPath returns an eviction path starting at bucket i or j whose last fingerprint can be moved to a free slot,
+// searched with the eviction strategy of the filter. It does not modify the table, so the path may be invalidated
+// by other writers before being executed
+func (s *StripedCuckooFilter) findPath(i uint, j uint) ([]pathStep, bool) {
+	if s.filter.eviction == BreadthFirst {
+		_, path, ok := s.filter.breadthFirstPath(i, j, nil, nil, s.slot, s.isFull)
+		return path, ok
+	}
+	var path []pathStep
+	k := i
+	if s.random()&1 != 0 {
+		k = j
+	}
+	for n := uint(0); n < s.filter.maxKicks; n++ {
+		pos := uint(s.random()) % s.filter.b
+		f := s.slot(k, pos)
+		path = append(path, pathStep{bucket: k, pos: pos, f: f})
+		k = s.filter.getAlternativePosition(k, f)
+		if !s.isFull(k) {
+			return path, true
+		}
+	}
+	return nil, false
+}
+
+// relocate moves the fingerprint of step to a free slot of its alternative bucket, if it is still in place and the slot still free
+func (s *StripedCuckooFilter) relocate(step pathStep) bool {
+	to := s.filter.getAlternativePosition(step.bucket, step.f)
+	s.lockPair(step.bucket, to)
+	defer s.unlockPair(step.bucket, to)
+	if s.filter.bucket(step.bucket).Get(step.pos) != step.f || s.filter.bucket(to).isFull() {
+		return false
+	}
+	atomic.AddUint64(&s.relocations, 1)
+	s.filter.bucket(to).Add(step.f)
+	s.filter.bucket(step.bucket).deletePos(step.pos)
+	return true
+}
+
+// random returns the next pseudo-random number of the source of the filter
+func (s *StripedCuckooFilter) random() int64 {
+	s.randomMu.Lock()
+	defer s.randomMu.Unlock()
+	return s.filter.random.Int63()
+}
+
+func (s *StripedCuckooFilter) slot(k uint, pos uint) uint64 {
+	s.rlock(k)
+	defer s.runlock(k)
+	return s.filter.bucket(k).Get(pos)
+}
+
+func (s *StripedCuckooFilter) isFull(k uint) bool {
+	s.rlock(k)
+	defer s.runlock(k)
+	return s.filter.bucket(k).isFull()
+}
+
+func (s *StripedCuckooFilter) contains(k uint, f uint64) bool {
+	s.rlock(k)
+	defer s.runlock(k)
+	ok, _ := s.filter.bucket(k).isElement(f)
+	return ok
+}
+
+func (s *StripedCuckooFilter) stripe(k uint) uint {
+	return k / s.bucketsPerStripe
+}
+
+func (s *StripedCuckooFilter) rlock(k uint) {
+	s.stripes[s.stripe(k)].RLock()
+}
+
+func (s *StripedCuckooFilter) runlock(k uint) {
+	s.stripes[s.stripe(k)].RUnlock()
+}
+
+// lockPair locks the stripes of buckets i and j in increasing order, so concurrent writers cannot deadlock
+func (s *StripedCuckooFilter) lockPair(i uint, j uint) {
+	a, b := s.orderedStripes(i, j)
+	s.stripes[a].Lock()
+	if a != b {
+		s.stripes[b].Lock()
+	}
+}
+
+func (s *StripedCuckooFilter) unlockPair(i uint, j uint) {
+	a, b := s.orderedStripes(i, j)
+	if a != b {
+		s.stripes[b].Unlock()
+	}
+	s.stripes[a].Unlock()
+}
+
+func (s *StripedCuckooFilter) rlockPair(i uint, j uint) {
+	a, b := s.orderedStripes(i, j)
+	s.stripes[a].RLock()
+	if a != b {
+		s.stripes[b].RLock()
+	}
+}
+
+func (s *StripedCuckooFilter) runlockPair(i uint, j uint) {
+	a, b := s.orderedStripes(i, j)
+	if a != b {
+		s.stripes[b].RUnlock()
+	}
+	s.stripes[a].RUnlock()
+}
+
+func (s *StripedCuckooFilter) orderedStripes(i uint, j uint) (uint, uint) {
+	a, b := s.stripe(i), s.stripe(j)
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+func gcd(a uint, b uint) uint {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
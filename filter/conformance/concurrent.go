@@ -0,0 +1,107 @@
+package conformance
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"sync"
+	"testing"
+)
+
+// goroutines is the number of goroutines that share the filter in the concurrent suite
+const goroutines = 8
+
+// RunConcurrent runs the conformance suite and then hammers the filters created by newFilter from many goroutines.
+// The filters must be safe for concurrent use. Run it with the race detector enabled
+func RunConcurrent(t *testing.T, newFilter Factory) {
+	Run(t, newFilter)
+	t.Run("ConcurrentInsertAndLookup", func(t *testing.T) { testConcurrentInsertAndLookup(t, newFilter) })
+	if _, ok := newFilter(capacity, targetError).(filter.DeletableFilter); ok {
+		t.Run("ConcurrentDelete", func(t *testing.T) { testConcurrentDelete(t, newFilter) })
+	}
+	if _, ok := newFilter(capacity, targetError).(filter.BatchFilter); ok {
+		t.Run("ConcurrentBatch", func(t *testing.T) { testConcurrentBatch(t, newFilter) })
+	}
+}
+
+// parallel runs work(g) in every goroutine g and waits for all of them
+func parallel(work func(g uint)) {
+	var wg sync.WaitGroup
+	for g := uint(0); g < goroutines; g++ {
+		wg.Add(1)
+		go func(g uint) {
+			defer wg.Done()
+			work(g)
+		}(g)
+	}
+	wg.Wait()
+}
+
+func testConcurrentInsertAndLookup(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError)
+	parallel(func(g uint) {
+		for i := g; i < capacity; i += goroutines {
+			if ok := f.Insert(element(i)); !ok {
+				t.Errorf("%s NOT correctly inserted.", element(i))
+				return
+			}
+			if ok := f.Lookup(element(i)); !ok {
+				t.Errorf("%s should be in right after being inserted.", element(i))
+			}
+			// Look up elements other goroutines may be inserting at the same time
+			f.Lookup(element((i + 1) % capacity))
+			f.TotalSize()
+		}
+	})
+	for i := uint(0); i < capacity; i++ {
+		if ok := f.Lookup(element(i)); !ok {
+			t.Errorf("%s should be in.", element(i))
+		}
+	}
+}
+
+func testConcurrentDelete(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError).(filter.DeletableFilter)
+	fill(t, f, capacity)
+	// Every goroutine deletes its even elements while checking its odd ones are kept
+	parallel(func(g uint) {
+		for i := 2 * g; i < capacity; i += 2 * goroutines {
+			if ok := f.Delete(element(i)); !ok {
+				t.Errorf("%s should be deleted.", element(i))
+			}
+			if i+1 < capacity {
+				if ok := f.Lookup(element(i + 1)); !ok {
+					t.Errorf("%s should be in.", element(i+1))
+				}
+			}
+		}
+	})
+	for i := uint(1); i < capacity; i += 2 {
+		if ok := f.Lookup(element(i)); !ok {
+			t.Errorf("%s should be in.", element(i))
+		}
+	}
+}
+
+func testConcurrentBatch(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError).(filter.BatchFilter)
+	// Every goroutine inserts its own range of elements and looks up the whole range
+	size := capacity / goroutines
+	parallel(func(g uint) {
+		if err := f.InsertBatch(elements(g*size, (g+1)*size)); err != nil {
+			t.Errorf("Batch NOT correctly inserted: %v", err)
+		}
+		results := make([]bool, capacity)
+		f.LookupBatch(elements(0, capacity), results)
+		for i := g * size; i < (g+1)*size; i++ {
+			if !results[i] {
+				t.Errorf("%s should be in right after being inserted.", element(i))
+			}
+		}
+	})
+	results := make([]bool, goroutines*size)
+	f.LookupBatch(elements(0, goroutines*size), results)
+	for i, ok := range results {
+		if !ok {
+			t.Errorf("%s should be in.", element(uint(i)))
+		}
+	}
+}
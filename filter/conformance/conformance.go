@@ -0,0 +1,220 @@
+package conformance
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+const (
+	capacity     = uint(1000)
+	targetError  = 0.001
+	falseLookups = 10000
+	// Margin over the expected number of false positives tolerated by the suite
+	errorMargin = 3
+)
+
+// Factory creates a new empty filter that can hold n elements with e false positive error
+type Factory func(n uint, e float64) filter.Filter
+
+// Run runs the conformance suite shared by every filter against the filters created by newFilter
+func Run(t *testing.T, newFilter Factory) {
+	t.Run("InsertAndLookup", func(t *testing.T) { testInsertAndLookup(t, newFilter) })
+	t.Run("FalsePositives", func(t *testing.T) { testFalsePositives(t, newFilter) })
+	t.Run("TotalSize", func(t *testing.T) { testTotalSize(t, newFilter) })
+	if _, ok := newFilter(capacity, targetError).(filter.DeletableFilter); ok {
+		t.Run("Delete", func(t *testing.T) { testDelete(t, newFilter) })
+	}
+	if _, ok := newFilter(capacity, targetError).(filter.UniqueInserter); ok {
+		t.Run("InsertUnique", func(t *testing.T) { testInsertUnique(t, newFilter) })
+	}
+	if _, ok := newFilter(capacity, targetError).(filter.BatchFilter); ok {
+		t.Run("Batch", func(t *testing.T) { testBatch(t, newFilter) })
+	}
+	if _, ok := newFilter(capacity, targetError).(filter.StatsReporter); ok {
+		t.Run("Stats", func(t *testing.T) { testStats(t, newFilter) })
+	}
+}
+
+func element(i uint) []byte {
+	return []byte(fmt.Sprintf("conformance-%d", i))
+}
+
+func fill(t *testing.T, f filter.Filter, n uint) {
+	for i := uint(0); i < n; i++ {
+		if ok := f.Insert(element(i)); !ok {
+			t.Fatalf("%s NOT correctly inserted.", element(i))
+		}
+	}
+}
+
+func testInsertAndLookup(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError)
+	fill(t, f, capacity)
+	for i := uint(0); i < capacity; i++ {
+		if ok := f.Lookup(element(i)); !ok {
+			t.Errorf("%s should be in.", element(i))
+		}
+	}
+}
+
+func testFalsePositives(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError)
+	fill(t, f, capacity)
+	falsePositives := 0
+	for i := capacity; i < capacity+falseLookups; i++ {
+		if f.Lookup(element(i)) {
+			falsePositives++
+		}
+	}
+	expectedFalsePositives := int(float64(falseLookups) * targetError)
+	if falsePositives > errorMargin*(expectedFalsePositives+1) {
+		t.Errorf("Error: Expected false positives are around %d and current false positives are %d", expectedFalsePositives, falsePositives)
+	}
+}
+
+func testTotalSize(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError)
+	if f.TotalSize() == 0 {
+		t.Errorf("Total size should be greater than 0")
+	}
+}
+
+func testDelete(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError).(filter.DeletableFilter)
+	fill(t, f, capacity)
+	deleted := 0
+	for i := uint(0); i < capacity; i += 2 {
+		if ok := f.Delete(element(i)); !ok {
+			t.Errorf("%s should be deleted.", element(i))
+		}
+		deleted++
+	}
+	for i := uint(1); i < capacity; i += 2 {
+		if ok := f.Lookup(element(i)); !ok {
+			t.Errorf("%s should be in.", element(i))
+		}
+	}
+	stillIn := 0
+	for i := uint(0); i < capacity; i += 2 {
+		if f.Lookup(element(i)) {
+			stillIn++
+		}
+	}
+	expectedStillIn := int(float64(deleted) * targetError)
+	if stillIn > errorMargin*(expectedStillIn+1) {
+		t.Errorf("Error: Expected deleted elements found are around %d and current found are %d", expectedStillIn, stillIn)
+	}
+}
+
+func testInsertUnique(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError)
+	u := f.(filter.UniqueInserter)
+	elem := []byte("Same Element")
+	for i := 0; i < 3; i++ {
+		if ok := u.InsertUnique(elem); !ok {
+			t.Fatalf("%s NOT correctly inserted in.", elem)
+		}
+	}
+	if ok := f.Lookup(elem); !ok {
+		t.Errorf("%s should be in.", elem)
+	}
+	d, ok := f.(filter.DeletableFilter)
+	if !ok {
+		return
+	}
+	if ok := d.Delete(elem); !ok {
+		t.Errorf("%s should be deleted.", elem)
+	}
+	if ok := d.Lookup(elem); ok {
+		t.Errorf("%s should NOT be in.", elem)
+	}
+}
+
+func elements(start uint, end uint) [][]byte {
+	batch := make([][]byte, 0, end-start)
+	for i := start; i < end; i++ {
+		batch = append(batch, element(i))
+	}
+	return batch
+}
+
+func testBatch(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError).(filter.BatchFilter)
+	if err := f.InsertBatch(elements(0, capacity)); err != nil {
+		t.Fatalf("Batch NOT correctly inserted: %v", err)
+	}
+	batch := elements(0, capacity+falseLookups)
+	results := make([]bool, len(batch))
+	f.LookupBatch(batch, results)
+	for i, elem := range batch {
+		if results[i] != f.Lookup(elem) {
+			t.Errorf("LookupBatch and Lookup of %s differ", elem)
+		}
+		if uint(i) < capacity && !results[i] {
+			t.Errorf("%s should be in.", elem)
+		}
+	}
+	d, ok := f.(filter.BatchDeleter)
+	if !ok {
+		return
+	}
+	// Delete the even elements and some that were never inserted, which must be reported as failed
+	var toDelete [][]byte
+	for i := uint(0); i < capacity; i += 2 {
+		toDelete = append(toDelete, element(i))
+	}
+	deleted := len(toDelete)
+	toDelete = append(toDelete, elements(capacity, capacity+100)...)
+	var batchErr *filter.BatchError
+	if err := d.DeleteBatch(toDelete); !errors.As(err, &batchErr) {
+		t.Fatalf("Deleting elements never inserted should return a *filter.BatchError, got %v", err)
+	}
+	if batchErr.Total != len(toDelete) || len(batchErr.Failed) == 0 || batchErr.Failed[0] < deleted {
+		t.Errorf("Expected only elements never inserted to fail, Current failed %v of %d", batchErr.Failed, batchErr.Total)
+	}
+	for i := uint(1); i < capacity; i += 2 {
+		if ok := f.Lookup(element(i)); !ok {
+			t.Errorf("%s should be in.", element(i))
+		}
+	}
+}
+
+func testStats(t *testing.T, newFilter Factory) {
+	f := newFilter(capacity, targetError)
+	r := f.(filter.StatsReporter)
+	empty := r.Stats()
+	if empty.Count != 0 || empty.LoadFactor != 0 || empty.FalsePositiveRate != 0 || empty.BitsPerElement() != 0 {
+		t.Errorf("Expected the stats of an empty filter to be 0, got %+v", empty)
+	}
+	if empty.Capacity == 0 || empty.TargetError == 0 || empty.MemoryBytes < f.TotalSize() {
+		t.Errorf("Expected the capacity, the target error and at least %d bytes of memory, got %+v", f.TotalSize(), empty)
+	}
+	fill(t, f, capacity)
+	stats := r.Stats()
+	// The estimations of the Bloom filters are within a few standard deviations of the exact count
+	if stats.Count != capacity && (!stats.CountIsEstimate || math.Abs(float64(stats.Count)-float64(capacity)) > 0.05*float64(capacity)) {
+		t.Errorf("Expected count %d, Current count %d (estimated %t)", capacity, stats.Count, stats.CountIsEstimate)
+	}
+	if stats.LoadFactor <= 0 || stats.LoadFactor > 1 {
+		t.Errorf("Expected load factor between 0 and 1, Current load factor %v", stats.LoadFactor)
+	}
+	if stats.FalsePositiveRate <= 0 || stats.FalsePositiveRate > 2*errorMargin*targetError {
+		t.Errorf("Expected false positive rate around %v, Current false positive rate %v", targetError, stats.FalsePositiveRate)
+	}
+	if bits := stats.BitsPerElement(); bits != float64(stats.MemoryBytes*8)/float64(stats.Count) {
+		t.Errorf("Expected %d bytes over %d elements, Current bits per element %v", stats.MemoryBytes, stats.Count, bits)
+	}
+	d, ok := f.(filter.DeletableFilter)
+	if !ok || stats.CountIsEstimate {
+		return
+	}
+	for i := uint(0); i < capacity; i += 2 {
+		d.Delete(element(i))
+	}
+	if count := r.Stats().Count; count != capacity/2 {
+		t.Errorf("Expected count %d after deleting half of the elements, Current count %d", capacity/2, count)
+	}
+}
@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidParameter is matched by every ParameterError, so callers can check errors.Is(err, ErrInvalidParameter)
+var ErrInvalidParameter = errors.New("invalid filter parameter")
+
+// ParameterError is returned by the constructors of the filters when a parameter is not valid
+type ParameterError struct {
+	// Filter is the package of the filter that rejected the parameter
+	Filter string
+	// Parameter is the name of the parameter
+	Parameter string
+	Value     interface{}
+	// Reason describes the values that are accepted
+	Reason string
+}
+
+func (e *ParameterError) Error() string {
+	return fmt.Sprintf("%s: invalid %s %v: %s", e.Filter, e.Parameter, e.Value, e.Reason)
+}
+
+// Is reports whether target is ErrInvalidParameter
+func (e *ParameterError) Is(target error) bool {
+	return target == ErrInvalidParameter
+}
+
+// ErrIncompatible is matched by every IncompatibleError, so callers can check errors.Is(err, ErrIncompatible)
+var ErrIncompatible = errors.New("incompatible filters")
+
+// IncompatibleError is returned when combining two filters whose parameters differ
+type IncompatibleError struct {
+	// Filter is the package of the filters
+	Filter string
+	// Parameter is the name of the first parameter that differs
+	Parameter string
+	Value     interface{}
+	Other     interface{}
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("%s: incompatible filters: %s %v and %v", e.Filter, e.Parameter, e.Value, e.Other)
+}
+
+// Is reports whether target is ErrIncompatible
+func (e *IncompatibleError) Is(target error) bool {
+	return target == ErrIncompatible
+}
+
+// BatchError is returned by the batch operations when they failed for some of the elements
+type BatchError struct {
+	// Op is the operation that failed
+	Op string
+	// Failed are the indexes of the elements the operation failed for, in increasing order
+	Failed []int
+	// Total is the number of elements of the batch
+	Total int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s failed for %d of %d elements", e.Op, len(e.Failed), e.Total)
+}
+
+// BatchResult returns a *BatchError with the indexes where ok is false, or nil if every element succeeded
+func BatchResult(op string, ok []bool) error {
+	var failed []int
+	for i, succeeded := range ok {
+		if !succeeded {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Op: op, Failed: failed, Total: len(ok)}
+}
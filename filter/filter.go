@@ -0,0 +1,71 @@
+package filter
+
+// Filter is the interface implemented by every probabilistic membership filter
+type Filter interface {
+	// Insert inserts element into the filter. Returns true if element has been inserted, false otherwise
+	Insert(element []byte) bool
+	// Lookup returns true if element may belong to the filter and false if element does not belong to the filter
+	Lookup(element []byte) bool
+	// TotalSize returns an estimation (in bytes) of the size of the array that represents the filter
+	TotalSize() uint
+}
+
+// DeletableFilter is a Filter that also supports removing elements
+type DeletableFilter interface {
+	Filter
+	// Delete deletes element in the filter. Returns true if element has been deleted, false otherwise
+	Delete(element []byte) bool
+}
+
+// UniqueInserter is implemented by filters that can insert an element only if it is not already inserted
+type UniqueInserter interface {
+	// InsertUnique inserts element if element is not already inserted. Returns true if element has been inserted or already exists, false otherwise
+	InsertUnique(element []byte) bool
+}
+
+// BatchFilter is a Filter that also inserts and looks up many elements at once, hashing them in bulk and visiting
+// their positions in increasing order
+type BatchFilter interface {
+	Filter
+	// InsertBatch inserts elements. Returns a *BatchError holding the elements that could not be inserted, nil if all of them have been inserted
+	InsertBatch(elements [][]byte) error
+	// LookupBatch sets results[i] to the result of looking up elements[i]. results must be at least as long as elements
+	LookupBatch(elements [][]byte, results []bool)
+}
+
+// BatchDeleter is implemented by filters that delete many elements at once
+type BatchDeleter interface {
+	// DeleteBatch deletes elements. Returns a *BatchError holding the elements that could not be deleted, nil if all of them have been deleted
+	DeleteBatch(elements [][]byte) error
+}
+
+// Stats are the live statistics of a filter
+type Stats struct {
+	// Count is the number of elements the filter holds. It is estimated from the set bits for the Bloom filters, as CountIsEstimate reports
+	Count           uint
+	CountIsEstimate bool
+	// LoadFactor is the fraction of the filter in use: the set bits or counters of the Bloom filters, the used slots of the Cuckoo and Quotient filters
+	LoadFactor float64
+	// Capacity is the number of elements n the filter was sized for
+	Capacity uint
+	// TargetError is the false positive error e the filter was sized for
+	TargetError float64
+	// FalsePositiveRate is the theoretical false positive rate of the filter with its current load
+	FalsePositiveRate float64
+	// MemoryBytes is the size (in bytes) of the arrays that represent the filter
+	MemoryBytes uint
+}
+
+// BitsPerElement returns the bits of memory used per element held, 0 if the filter is empty
+func (s Stats) BitsPerElement() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.MemoryBytes*8) / float64(s.Count)
+}
+
+// StatsReporter is implemented by the filters that report their live statistics
+type StatsReporter interface {
+	// Stats returns the statistics of the filter. Computational time: O(1) for the Cuckoo and Quotient filters, O(m) for the Bloom filters
+	Stats() Stats
+}
@@ -3,6 +3,6 @@ module ProbabilisticDataStructures
 go 1.17
 
 require (
-	github.com/bits-and-blooms/bitset v1.2.1 // indirect
-	github.com/spaolacci/murmur3 v1.1.0 // indirect
+	github.com/bits-and-blooms/bitset v1.2.1
+	github.com/spaolacci/murmur3 v1.1.0
 )
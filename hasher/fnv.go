@@ -0,0 +1,38 @@
+package hasher
+
+import "math/bits"
+
+const (
+	fnvOffset64    uint64 = 14695981039346656037
+	fnvPrime64     uint64 = 1099511628211
+	fnvOffset128Hi uint64 = 0x6c62272e07bb0142
+	fnvOffset128Lo uint64 = 0x62b821756295c58d
+	// fnvPrime128Lo is the low word of the 128-bit prime 2^88 + 0x13b
+	fnvPrime128Lo    uint64 = 0x13b
+	fnvPrime128Shift        = 88 - 64
+)
+
+// FNV1a is a Hasher based on FNV-1a. The seed is xor'ed into the offset basis, so seed 0 gives the standard FNV-1a
+type FNV1a struct{}
+
+// Sum64 returns the 64-bit FNV-1a of data using seed
+func (FNV1a) Sum64(data []byte, seed uint32) uint64 {
+	h := fnvOffset64 ^ uint64(seed)
+	for _, c := range data {
+		h ^= uint64(c)
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// Sum128 returns the 128-bit FNV-1a of data using seed, as its high and low words
+func (FNV1a) Sum128(data []byte, seed uint32) (uint64, uint64) {
+	hi, lo := fnvOffset128Hi, fnvOffset128Lo^uint64(seed)
+	for _, c := range data {
+		lo ^= uint64(c)
+		h, l := bits.Mul64(lo, fnvPrime128Lo)
+		hi = h + hi*fnvPrime128Lo + lo<<fnvPrime128Shift
+		lo = l
+	}
+	return hi, lo
+}
@@ -0,0 +1,116 @@
+package hasher
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// Hasher computes the hashes the filters derive their positions and fingerprints from
+type Hasher interface {
+	// Sum64 returns the 64-bit hash of data using seed
+	Sum64(data []byte, seed uint32) uint64
+	// Sum128 returns the 128-bit hash of data using seed, as its two 64-bit halves
+	Sum128(data []byte, seed uint32) (uint64, uint64)
+}
+
+// Algorithm identifies a built-in Hasher in the serialized form of the filters
+type Algorithm uint8
+
+const (
+	// AlgorithmCustom identifies any Hasher that is not built in. It cannot be serialized
+	AlgorithmCustom Algorithm = iota
+	AlgorithmMurmur3
+	AlgorithmXXHash
+	AlgorithmFNV1a
+	AlgorithmSipHash
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case AlgorithmMurmur3:
+		return "murmur3"
+	case AlgorithmXXHash:
+		return "xxhash"
+	case AlgorithmFNV1a:
+		return "fnv1a"
+	case AlgorithmSipHash:
+		return "siphash"
+	}
+	return "custom"
+}
+
+var (
+	// ErrCustomHasher is returned when encoding a Hasher that is not built in
+	ErrCustomHasher = errors.New("hasher: only the built-in hashers can be encoded")
+	// ErrUnknownAlgorithm is returned when decoding an algorithm that does not identify a built-in Hasher
+	ErrUnknownAlgorithm = errors.New("hasher: unknown algorithm")
+)
+
+// AlgorithmOf returns the algorithm of h, or AlgorithmCustom if h is not built in
+func AlgorithmOf(h Hasher) Algorithm {
+	switch h.(type) {
+	case Murmur3:
+		return AlgorithmMurmur3
+	case XXHash:
+		return AlgorithmXXHash
+	case FNV1a:
+		return AlgorithmFNV1a
+	case SipHash:
+		return AlgorithmSipHash
+	}
+	return AlgorithmCustom
+}
+
+// Equal returns true if a and b are the same Hasher: the same built-in hasher with the same key, or equal values of a comparable custom type
+func Equal(a Hasher, b Hasher) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// Encode returns the algorithm and the key (zero for unkeyed hashers) that identify h in the serialized form of the filters
+func Encode(h Hasher) (Algorithm, [2]uint64, error) {
+	a := AlgorithmOf(h)
+	if a == AlgorithmCustom {
+		return a, [2]uint64{}, ErrCustomHasher
+	}
+	var key [2]uint64
+	if s, ok := h.(SipHash); ok {
+		key = [2]uint64{s.k0, s.k1}
+	}
+	return a, key, nil
+}
+
+// Decode returns the Hasher identified by a and key, as returned by Encode
+func Decode(a Algorithm, key [2]uint64) (Hasher, error) {
+	if a != AlgorithmSipHash && key != [2]uint64{} {
+		return nil, ErrUnknownAlgorithm
+	}
+	switch a {
+	case AlgorithmMurmur3:
+		return Murmur3{}, nil
+	case AlgorithmXXHash:
+		return XXHash{}, nil
+	case AlgorithmFNV1a:
+		return FNV1a{}, nil
+	case AlgorithmSipHash:
+		return SipHash{k0: key[0], k1: key[1]}, nil
+	}
+	return nil, ErrUnknownAlgorithm
+}
+
+// Murmur3 is the default Hasher, based on the 64-bit MurmurHash3
+type Murmur3 struct{}
+
+// Sum64 returns the 64-bit MurmurHash3 of data using seed
+func (Murmur3) Sum64(data []byte, seed uint32) uint64 {
+	return murmur3.Sum64WithSeed(data, seed)
+}
+
+// Sum128 returns the 128-bit MurmurHash3 of data using seed
+func (Murmur3) Sum128(data []byte, seed uint32) (uint64, uint64) {
+	return murmur3.Sum128WithSeed(data, seed)
+}
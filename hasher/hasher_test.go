@@ -0,0 +1,133 @@
+package hasher
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"testing"
+)
+
+func TestXXHashKnownValues(t *testing.T) {
+	cases := []struct {
+		data     string
+		expected uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+	for _, c := range cases {
+		if h := (XXHash{}).Sum64([]byte(c.data), 0); h != c.expected {
+			t.Errorf("XXH64(%q) expected %#x, Current %#x", c.data, c.expected, h)
+		}
+	}
+}
+
+func TestFNV1aMatchesStandardLibrary(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		data := []byte(fmt.Sprintf("element %d", i))
+		h64 := fnv.New64a()
+		h64.Write(data)
+		if h := (FNV1a{}).Sum64(data, 0); h != h64.Sum64() {
+			t.Errorf("FNV-1a 64 of %q expected %#x, Current %#x", data, h64.Sum64(), h)
+		}
+		h128 := fnv.New128a()
+		h128.Write(data)
+		sum := h128.Sum(nil)
+		hi, lo := (FNV1a{}).Sum128(data, 0)
+		if hi != binary.BigEndian.Uint64(sum) || lo != binary.BigEndian.Uint64(sum[8:]) {
+			t.Errorf("FNV-1a 128 of %q expected %x, Current %016x%016x", data, sum, hi, lo)
+		}
+	}
+}
+
+func TestSipHashKnownValues(t *testing.T) {
+	var key [16]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	s := NewSipHash(key)
+	message := make([]byte, 15)
+	for i := range message {
+		message[i] = byte(i)
+	}
+	if h := s.Sum64(nil, 0); h != 0x726fdb47dd0e0e31 {
+		t.Errorf("SipHash-2-4 of the empty message expected %#x, Current %#x", uint64(0x726fdb47dd0e0e31), h)
+	}
+	if h := s.Sum64(message, 0); h != 0xa129ca6149be45e5 {
+		t.Errorf("SipHash-2-4 of 15 bytes expected %#x, Current %#x", uint64(0xa129ca6149be45e5), h)
+	}
+	h1, h2 := s.Sum128(nil, 0)
+	if h1 != 0xe6a825ba047f81a3 || h2 != 0x930255c71472f66d {
+		t.Errorf("SipHash-2-4-128 of the empty message expected %#x %#x, Current %#x %#x", uint64(0xe6a825ba047f81a3), uint64(0x930255c71472f66d), h1, h2)
+	}
+	if s.Sum64(message, 0) == NewSipHash([16]byte{}).Sum64(message, 0) {
+		t.Errorf("A different key should give a different hash")
+	}
+}
+
+func TestSeedChangesHash(t *testing.T) {
+	data := []byte("element")
+	for _, h := range []Hasher{Murmur3{}, XXHash{}, FNV1a{}, SipHash{}} {
+		if h.Sum64(data, 0) == h.Sum64(data, 1) {
+			t.Errorf("%v: seeds 0 and 1 should give different hashes", AlgorithmOf(h))
+		}
+		a1, a2 := h.Sum128(data, 0)
+		b1, b2 := h.Sum128(data, 1)
+		if a1 == b1 && a2 == b2 {
+			t.Errorf("%v: seeds 0 and 1 should give different 128-bit hashes", AlgorithmOf(h))
+		}
+	}
+}
+
+type customHasher struct {
+	Murmur3
+}
+
+func TestEncodeAndDecode(t *testing.T) {
+	for _, h := range []Hasher{Murmur3{}, XXHash{}, FNV1a{}, NewSipHash([16]byte{1, 2, 3})} {
+		a, key, err := Encode(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := Decode(a, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != h {
+			t.Errorf("%v: expected %v, Current %v", a, h, decoded)
+		}
+	}
+	if _, _, err := Encode(customHasher{}); err != ErrCustomHasher {
+		t.Errorf("Expected %v, Current %v", ErrCustomHasher, err)
+	}
+	for _, a := range []Algorithm{AlgorithmCustom, AlgorithmSipHash + 1} {
+		if _, err := Decode(a, [2]uint64{}); err != ErrUnknownAlgorithm {
+			t.Errorf("%d: expected %v, Current %v", a, ErrUnknownAlgorithm, err)
+		}
+	}
+}
+
+type sliceHasher struct {
+	FNV1a
+	salt []byte
+}
+
+func TestEqual(t *testing.T) {
+	cases := []struct {
+		a, b  Hasher
+		equal bool
+	}{
+		{Murmur3{}, Murmur3{}, true},
+		{Murmur3{}, XXHash{}, false},
+		{NewSipHash([16]byte{1}), NewSipHash([16]byte{1}), true},
+		{NewSipHash([16]byte{1}), NewSipHash([16]byte{2}), false},
+		{sliceHasher{}, sliceHasher{}, false},
+	}
+	for _, c := range cases {
+		if Equal(c.a, c.b) != c.equal {
+			t.Errorf("Equal(%#v, %#v) should be %t", c.a, c.b, c.equal)
+		}
+	}
+}
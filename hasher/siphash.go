@@ -0,0 +1,83 @@
+package hasher
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// SipHash is a keyed Hasher based on SipHash-2-4. Without the key, an adversary cannot choose elements that collide.
+// The seed is xor'ed into the first half of the key, so seed 0 gives the standard SipHash-2-4
+type SipHash struct {
+	k0 uint64
+	k1 uint64
+}
+
+// NewSipHash creates a SipHash with the 128-bit key
+func NewSipHash(key [16]byte) SipHash {
+	return SipHash{
+		k0: binary.LittleEndian.Uint64(key[0:]),
+		k1: binary.LittleEndian.Uint64(key[8:]),
+	}
+}
+
+// Sum64 returns the 64-bit SipHash-2-4 of data using seed
+func (s SipHash) Sum64(data []byte, seed uint32) uint64 {
+	v0, v1, v2, v3 := s.compress(data, seed, 0)
+	v2 ^= 0xff
+	v0, v1, v2, v3 = sipRounds(v0, v1, v2, v3, 4)
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+// Sum128 returns the 128-bit SipHash-2-4 of data using seed, as its first and second words
+func (s SipHash) Sum128(data []byte, seed uint32) (uint64, uint64) {
+	v0, v1, v2, v3 := s.compress(data, seed, 0xee)
+	v2 ^= 0xee
+	v0, v1, v2, v3 = sipRounds(v0, v1, v2, v3, 4)
+	h1 := v0 ^ v1 ^ v2 ^ v3
+	v1 ^= 0xdd
+	v0, v1, v2, v3 = sipRounds(v0, v1, v2, v3, 4)
+	return h1, v0 ^ v1 ^ v2 ^ v3
+}
+
+// compress initializes the state with the key and absorbs data, tweaking v1 with tweak
+func (s SipHash) compress(data []byte, seed uint32, tweak uint64) (uint64, uint64, uint64, uint64) {
+	k0, k1 := s.k0^uint64(seed), s.k1
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d ^ tweak
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+	last := uint64(len(data)) << 56
+	for ; len(data) >= 8; data = data[8:] {
+		m := binary.LittleEndian.Uint64(data)
+		v3 ^= m
+		v0, v1, v2, v3 = sipRounds(v0, v1, v2, v3, 2)
+		v0 ^= m
+	}
+	for i, c := range data {
+		last |= uint64(c) << (8 * uint(i))
+	}
+	v3 ^= last
+	v0, v1, v2, v3 = sipRounds(v0, v1, v2, v3, 2)
+	v0 ^= last
+	return v0, v1, v2, v3
+}
+
+func sipRounds(v0, v1, v2, v3 uint64, rounds int) (uint64, uint64, uint64, uint64) {
+	for i := 0; i < rounds; i++ {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+	return v0, v1, v2, v3
+}
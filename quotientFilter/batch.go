@@ -0,0 +1,79 @@
+package quotientFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+)
+
+const (
+	// batchChunkBits is the log2 of the number of elements whose quotients are sorted together by the batch operations
+	batchChunkBits = 12
+	batchChunk     = 1 << batchChunkBits
+	// batchRegions is the number of regions of the table the elements of a chunk are grouped by
+	batchRegions = 1024
+)
+
+var (
+	_ filter.BatchFilter  = (*QuotientFilter)(nil)
+	_ filter.BatchDeleter = (*QuotientFilter)(nil)
+)
+
+// hashedElement is the index of an element of a batch with the quotient and the reminder of its fingerprint
+type hashedElement struct {
+	index int
+	fq    uint
+	fr    uint64
+}
+
+// InsertBatch inserts elements into QF grouped by their quotient.
+// Returns a *filter.BatchError holding the elements that could not be inserted, nil if all of them have been inserted
+func (q *QuotientFilter) InsertBatch(elements [][]byte) error {
+	ok := make([]bool, len(elements))
+	q.forEachSortedElement(elements, func(e hashedElement) {
+		ok[e.index] = q.insert(e.fq, e.fr, false)
+	})
+	return filter.BatchResult("quotientFilter: insert", ok)
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in QF, visiting them grouped by their quotient
+func (q *QuotientFilter) LookupBatch(elements [][]byte, results []bool) {
+	q.forEachSortedElement(elements, func(e hashedElement) {
+		results[e.index] = q.lookup(e.fq, e.fr)
+	})
+}
+
+// DeleteBatch deletes elements in QF grouped by their quotient.
+// Returns a *filter.BatchError holding the elements that were not found, nil if all of them have been deleted
+func (q *QuotientFilter) DeleteBatch(elements [][]byte) error {
+	ok := make([]bool, len(elements))
+	q.forEachSortedElement(elements, func(e hashedElement) {
+		ok[e.index] = q.delete(e.fq, e.fr)
+	})
+	return filter.BatchResult("quotientFilter: delete", ok)
+}
+
+// forEachSortedElement hashes elements in chunks and calls fn with every element of a chunk grouped by region of the table,
+// in increasing order of region, so the table is scanned mostly sequentially. It only reads the parameters of q, so it can run concurrently
+func (q *QuotientFilter) forEachSortedElement(elements [][]byte, fn func(e hashedElement)) {
+	hashed := make([]hashedElement, 0, batchChunk)
+	keys := make([]uint64, 0, batchChunk)
+	sorted := make([]uint64, batchChunk)
+	counts := make([]int, batchRegions+1)
+	shift := utils.RegionShift(uint64(q.m-1)<<batchChunkBits|(batchChunk-1), batchRegions)
+	for start := 0; start < len(elements); start += batchChunk {
+		end := start + batchChunk
+		if end > len(elements) {
+			end = len(elements)
+		}
+		hashed, keys = hashed[:0], keys[:0]
+		for index := start; index < end; index++ {
+			fq, fr := q.getQuotientPosAndRest(q.getFingerprint(elements[index]))
+			hashed = append(hashed, hashedElement{index: index, fq: fq, fr: fr})
+			keys = append(keys, uint64(fq)<<batchChunkBits|uint64(index-start))
+		}
+		utils.RegionSort(keys, sorted, shift, counts)
+		for _, key := range sorted[:len(keys)] {
+			fn(hashed[key&(batchChunk-1)])
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package quotientFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"sync"
+)
+
+var (
+	_ filter.DeletableFilter = (*ConcurrentQuotientFilter)(nil)
+	_ filter.UniqueInserter  = (*ConcurrentQuotientFilter)(nil)
+	_ filter.BatchFilter     = (*ConcurrentQuotientFilter)(nil)
+	_ filter.BatchDeleter    = (*ConcurrentQuotientFilter)(nil)
+	_ container.Storable     = (*ConcurrentQuotientFilter)(nil)
+)
+
+// ConcurrentQuotientFilter is a Quotient Filter safe for concurrent use by multiple goroutines.
+// Lookups proceed in parallel, while inserts and deletes hold the filter exclusively
+type ConcurrentQuotientFilter struct {
+	mu     sync.RWMutex
+	filter *QuotientFilter
+	// parallelism is the number of goroutines LookupBatch fans out to
+	parallelism int
+}
+
+// NewConcurrent wraps q so it can be shared between goroutines. q must not be used directly afterwards
+func NewConcurrent(q *QuotientFilter) *ConcurrentQuotientFilter {
+	return &ConcurrentQuotientFilter{filter: q}
+}
+
+// SetParallelism sets the number of goroutines LookupBatch splits its elements between. By default it does not fan out.
+// It must be called before sharing the filter
+func (c *ConcurrentQuotientFilter) SetParallelism(goroutines int) {
+	c.parallelism = goroutines
+}
+
+// Insert inserts element into QF. Returns true if element has been inserted, false otherwise. Expected computational time: O(1)
+func (c *ConcurrentQuotientFilter) Insert(element []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.Insert(element)
+}
+
+// InsertUnique inserts element into QF if element is not already inserted, checking and inserting atomically.
+// Returns true if element has been inserted or already exists, false otherwise. Expected computational time: O(1)
+func (c *ConcurrentQuotientFilter) InsertUnique(element []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.InsertUnique(element)
+}
+
+// Lookup returns true if element may belong to the QF and false if element does not belong to the QF. Expected computational time: O(1)
+func (c *ConcurrentQuotientFilter) Lookup(element []byte) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.Lookup(element)
+}
+
+// Delete deletes element in the filter. Returns true if element has been deleted, false otherwise. Expected computational time: O(1)
+func (c *ConcurrentQuotientFilter) Delete(element []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.Delete(element)
+}
+
+// InsertBatch inserts elements into QF, holding the filter exclusively once for the whole batch.
+// Returns a *filter.BatchError holding the elements that could not be inserted, nil if all of them have been inserted
+func (c *ConcurrentQuotientFilter) InsertBatch(elements [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.InsertBatch(elements)
+}
+
+// LookupBatch sets results[i] to the result of looking up elements[i] in QF, splitting the elements between the goroutines set by SetParallelism
+func (c *ConcurrentQuotientFilter) LookupBatch(elements [][]byte, results []bool) {
+	utils.ParallelChunks(len(elements), c.parallelism, func(start int, end int) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		c.filter.LookupBatch(elements[start:end], results[start:end])
+	})
+}
+
+// DeleteBatch deletes elements in QF, holding the filter exclusively once for the whole batch.
+// Returns a *filter.BatchError holding the elements that were not found, nil if all of them have been deleted
+func (c *ConcurrentQuotientFilter) DeleteBatch(elements [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.filter.DeleteBatch(elements)
+}
+
+// TotalSize returns the size (in bytes) of the packed table that represents QF.
+func (c *ConcurrentQuotientFilter) TotalSize() uint {
+	return c.filter.TotalSize()
+}
+
+// Descriptor returns the description of QF written in the header of a container
+func (c *ConcurrentQuotientFilter) Descriptor() container.Descriptor {
+	return c.filter.Descriptor()
+}
+
+// MarshalBinary encodes a snapshot of QF, with the same encoding as QuotientFilter.MarshalBinary
+func (c *ConcurrentQuotientFilter) MarshalBinary() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.MarshalBinary()
+}
@@ -0,0 +1,31 @@
+package quotientFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/hasher"
+)
+
+var _ container.Storable = (*QuotientFilter)(nil)
+
+func init() {
+	container.Register(container.KindQuotient, func(payload []byte) (container.Storable, error) {
+		q := new(QuotientFilter)
+		if err := q.UnmarshalBinary(payload); err != nil {
+			return nil, err
+		}
+		return q, nil
+	})
+}
+
+// Descriptor returns the description of QF written in the header of a container
+func (q *QuotientFilter) Descriptor() container.Descriptor {
+	return container.Descriptor{
+		Kind:   container.KindQuotient,
+		Hash:   container.HashAlgorithm(hasher.AlgorithmOf(q.hasher)),
+		Seed:   uint64(q.seed),
+		N:      uint64(q.n),
+		M:      uint64(q.m),
+		E:      q.e,
+		Params: [2]uint64{uint64(q.q), uint64(q.r)},
+	}
+}
@@ -0,0 +1,92 @@
+package quotientFilter
+
+import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/utils"
+	"errors"
+)
+
+var _ filter.Filter = (*MmapQuotientFilter)(nil)
+
+// ErrWrongKind is returned when opening a container that does not store a QF
+var ErrWrongKind = errors.New("quotientFilter: container does not store a Quotient Filter")
+
+// MmapQuotientFilter is a read-only Quotient Filter that answers lookups directly against a memory-mapped container
+type MmapQuotientFilter struct {
+	filter QuotientFilter
+	data   []byte
+}
+
+// OpenMmap maps the container at path, written with container.Save, without copying its table into the heap.
+// The mapping is shared, so processes opening the same file share its pages. Close must be called to release it
+func OpenMmap(path string) (*MmapQuotientFilter, error) {
+	data, err := utils.Mmap(path)
+	if err != nil {
+		return nil, err
+	}
+	q, err := mapQF(data)
+	if err != nil {
+		utils.Munmap(data)
+		return nil, err
+	}
+	return &MmapQuotientFilter{filter: q, data: data}, nil
+}
+
+// Insert always returns false, as a memory-mapped QF is read-only
+func (q *MmapQuotientFilter) Insert(element []byte) bool {
+	return false
+}
+
+// Lookup returns true if element may belong to the QF and false if element does not belong to the QF. Expected computational time: O(1)
+func (q *MmapQuotientFilter) Lookup(element []byte) bool {
+	return q.filter.Lookup(element)
+}
+
+// TotalSize returns an estimation (in bytes) of the size of the array that represents QF.
+func (q *MmapQuotientFilter) TotalSize() uint {
+	return q.filter.TotalSize()
+}
+
+// Descriptor returns the description of QF written in the header of its container
+func (q *MmapQuotientFilter) Descriptor() container.Descriptor {
+	return q.filter.Descriptor()
+}
+
+// Close unmaps the QF. It must not be used afterwards
+func (q *MmapQuotientFilter) Close() error {
+	data := q.data
+	q.data = nil
+	q.filter.table = utils.PackedArray{}
+	return utils.Munmap(data)
+}
+
+func mapQF(data []byte) (QuotientFilter, error) {
+	h, payload, err := container.Split(data)
+	if err != nil {
+		return QuotientFilter{}, err
+	}
+	if h.Kind != container.KindQuotient {
+		return QuotientFilter{}, ErrWrongKind
+	}
+	header, words, err := utils.ReadWords(payload, headerWords)
+	if err != nil {
+		return QuotientFilter{}, ErrInvalidEncoding
+	}
+	q, err := decodeHeader(header)
+	if err != nil {
+		return QuotientFilter{}, err
+	}
+	if uint64(len(words)) != header[11]*utils.WordSize {
+		return QuotientFilter{}, ErrInvalidEncoding
+	}
+	table, err := utils.AliasWords(words)
+	if err != nil {
+		return QuotientFilter{}, err
+	}
+	q.table = utils.PackedArrayFrom(table, metadataBits+q.r)
+	if q.Descriptor() != h.Descriptor {
+		return QuotientFilter{}, container.ErrDescriptorMismatch
+	}
+	return q, nil
+}
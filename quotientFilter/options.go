@@ -0,0 +1,54 @@
+package quotientFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+)
+
+// Option configures a QF created with NewWithOptions or NewWithSize
+type Option func(*config) error
+
+type config struct {
+	seed   uint32
+	hasher hasher.Hasher
+}
+
+func newConfig(opts []Option) (config, error) {
+	cfg := config{
+		hasher: hasher.Murmur3{},
+	}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// WithSeed sets the seed of the hash function that computes the fingerprints. The default seed is 0
+func WithSeed(seed uint32) Option {
+	return func(c *config) error {
+		c.seed = seed
+		return nil
+	}
+}
+
+// WithHasher sets the hash function that computes the fingerprints. The default is hasher.Murmur3. Only the built-in hashers can be serialized
+func WithHasher(h hasher.Hasher) Option {
+	return func(c *config) error {
+		if h == nil {
+			return parameterError("hasher", h, "must not be nil")
+		}
+		c.hasher = h
+		return nil
+	}
+}
+
+func parameterError(parameter string, value interface{}, reason string) error {
+	return &filter.ParameterError{
+		Filter:    "quotientFilter",
+		Parameter: parameter,
+		Value:     value,
+		Reason:    reason,
+	}
+}
@@ -1,172 +1,232 @@
 package quotientFilter
 
 import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
 	"ProbabilisticDataStructures/utils"
 	"fmt"
 	"math"
-
-	"github.com/bits-and-blooms/bitset"
-	"github.com/spaolacci/murmur3"
 )
 
 const (
 	loadFactor = 0.65
+	// maxR is the widest supported reminder, so a slot and its metadata bits fit in a word
+	maxR = utils.Machine64Bits - metadataBits
+)
+
+var (
+	_ filter.DeletableFilter = (*QuotientFilter)(nil)
+	_ filter.UniqueInserter  = (*QuotientFilter)(nil)
 )
 
 type QuotientFilter struct {
-	n     uint
-	m     uint
-	q     uint
-	r     uint
-	e     float64
-	count uint
-	slots []slot
+	n      uint
+	m      uint
+	q      uint
+	r      uint
+	e      float64
+	count  uint
+	seed   uint32
+	hasher hasher.Hasher
+	// table holds the m slots of 3+r bits: the metadata bits followed by the reminder
+	table utils.PackedArray
 }
 
-// New creates a new Quotient Filter with desired length (in bits) of quotient and reminder
+// New creates a new Quotient Filter with desired length (in bits) of quotient and reminder.
+// It panics if the lengths are not valid, NewWithSize returns an error instead
 func New(q, r uint) QuotientFilter {
-	m := computeSizeM(q)
-	n := computeSizeN(m)
-	e := computeError(n, r+q)
-	return newQF(n, m, q, r, e)
+	f, err := NewWithSize(q, r)
+	if err != nil {
+		panic(err)
+	}
+	return *f
 }
 
-// NewFromSizeAndError creates a new Quotient Filter that can hold n elements with e false positive error
+// NewFromSizeAndError creates a new Quotient Filter that can hold n elements with e false positive error.
+// It panics if n is 0 or e is not between 0 and 1, NewWithOptions returns an error instead
 func NewFromSizeAndError(n uint, e float64) QuotientFilter {
+	f, err := NewWithOptions(n, e)
+	if err != nil {
+		panic(err)
+	}
+	return *f
+}
+
+// NewWithOptions creates a new Quotient Filter that can hold n elements with e false positive error, configured with opts
+func NewWithOptions(n uint, e float64, opts ...Option) (*QuotientFilter, error) {
+	if n == 0 {
+		return nil, parameterError("n", n, "capacity must be greater than 0")
+	}
+	if !(e > 0 && e < 1) {
+		return nil, parameterError("e", e, "false positive error must be between 0 and 1 (exclusive)")
+	}
 	q := computeSizeQ(n)
-	m := computeSizeM(q)
 	r := computeSizeR(n, q, e)
-	return newQF(n, m, q, r, e)
+	if err := validateSizes(q, r); err != nil {
+		return nil, err
+	}
+	return newQF(n, computeSizeM(q), q, r, e, opts)
+}
+
+// NewWithSize creates a new Quotient Filter with desired length (in bits) of quotient and reminder, configured with opts.
+// r must be between 1 and 61 bits and q+r at most 64 bits
+func NewWithSize(q, r uint, opts ...Option) (*QuotientFilter, error) {
+	if err := validateSizes(q, r); err != nil {
+		return nil, err
+	}
+	m := computeSizeM(q)
+	n := computeSizeN(m)
+	e := computeError(n, r+q)
+	return newQF(n, m, q, r, e, opts)
 }
 
 // Insert inserts element into QF. Expected computational time: O(1). Returns true if element has been inserted or already exists, false otherwise. Expected computational time: O(1)
 func (q *QuotientFilter) Insert(element []byte) bool {
-	f := getFingerprint(element)
+	f := q.getFingerprint(element)
 	fq, fr := q.getQuotientPosAndRest(f)
 	return q.insert(fq, fr, false)
 }
 
 // InsertUnique inserts element into QF if element is not already inserted. Returns true if element has been inserted or already exists, false otherwise. Expected computational time: O(1)
 func (q *QuotientFilter) InsertUnique(element []byte) bool {
-	f := getFingerprint(element)
+	f := q.getFingerprint(element)
 	fq, fr := q.getQuotientPosAndRest(f)
 	return q.insert(fq, fr, true)
 }
 
 // Lookup returns true if element may belong to the QF and false if element does not belong to the QF. Expected computational time: O(1)
-func (q QuotientFilter) Lookup(element []byte) bool {
-	f := getFingerprint(element)
+func (q *QuotientFilter) Lookup(element []byte) bool {
+	f := q.getFingerprint(element)
 	fq, fr := q.getQuotientPosAndRest(f)
 	return q.lookup(fq, fr)
 }
 
 // Delete deletes element in the filter. Returns true if element has been deleted, false otherwise. Expected computational time: O(1)
 func (q *QuotientFilter) Delete(element []byte) bool {
-	f := getFingerprint(element)
+	f := q.getFingerprint(element)
 	fq, fr := q.getQuotientPosAndRest(f)
 	return q.delete(fq, fr)
 }
 
 // Print prints a representation of the current Quotient filter
-func (q QuotientFilter) Print() {
-	for _, slot := range q.slots {
+func (q *QuotientFilter) Print() {
+	for i := uint(0); i < q.m; i++ {
+		slot := q.slot(i)
 		s := "empty"
-		if slot.getReminder() != nil {
-			s = fmt.Sprint(utils.BitSetToUint(slot.getReminder()))
+		if !slot.isEmpty() {
+			s = fmt.Sprint(slot.getReminder())
 		}
-		fmt.Printf("  %t\t| %t\t| %t\t| => %s\n", slot.isOccupied, slot.isContinuation, slot.isShifted, s)
+		fmt.Printf("  %t\t| %t\t| %t\t| => %s\n", slot.isOccupied(), slot.isContinuation(), slot.isShifted(), s)
 	}
 	fmt.Println()
 }
 
-// TotalSize returns an estimation (in bytes) of the size of the array that represents QF.
-func (q QuotientFilter) TotalSize() uint {
-	sizeR := q.r/utils.ByteSize
-	if q.r % utils.ByteSize != 0 {
-		sizeR++
-	}
-	return q.m * ((3 * utils.ByteSize) + sizeR)
+// TotalSize returns the size (in bytes) of the packed table that represents QF, that is m*(3+r) bits rounded up to words.
+func (q *QuotientFilter) TotalSize() uint {
+	return uint(len(q.table.Words())) * utils.WordSize
 }
 
-func newQF(n uint, m uint, q uint, r uint, e float64) QuotientFilter {
-	if q+r > 64 {
-		//panic("Error")
+func validateSizes(q, r uint) error {
+	if q == 0 {
+		return parameterError("q", q, "quotient must have at least 1 bit")
 	}
-	return QuotientFilter{
-		n:     n,
-		e:     e,
-		m:     m,
-		r:     r,
-		q:     q,
-		slots: make([]slot, m),
+	if r == 0 || r > maxR {
+		return parameterError("r", r, fmt.Sprintf("reminder must be between 1 and %d bits", maxR))
 	}
+	if q+r > utils.Machine64Bits {
+		return parameterError("q+r", q+r, fmt.Sprintf("quotient and reminder must fit in the %d-bit fingerprint", utils.Machine64Bits))
+	}
+	return nil
+}
+
+func newQF(n uint, m uint, q uint, r uint, e float64, opts []Option) (*QuotientFilter, error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotientFilter{
+		n:      n,
+		e:      e,
+		m:      m,
+		r:      r,
+		q:      q,
+		seed:   cfg.seed,
+		hasher: cfg.hasher,
+		table:  utils.NewPackedArray(m, metadataBits+r),
+	}, nil
+}
+
+func (q *QuotientFilter) slot(i uint) slot {
+	return slot(q.table.Get(i))
 }
 
-func (q *QuotientFilter) insert(fq uint, fr *bitset.BitSet, unique bool) bool {
-	if q.slots[fq].isEmpty() {
-		q.slots[fq].add(fr)
-		q.slots[fq].isOccupied = true
+func (q *QuotientFilter) setSlot(i uint, s slot) {
+	q.table.Set(i, uint64(s))
+}
+
+func (q *QuotientFilter) insert(fq uint, fr uint64, unique bool) bool {
+	if q.slot(fq).isEmpty() {
+		q.setSlot(fq, newSlot(fr).setOccupied(true))
+		q.count++
 		return true
 	}
-	insertSlot := new(slot)
-	insertSlot.add(fr)
-	wasOccupied := q.slots[fq].isOccupied
-	q.slots[fq].isOccupied = true
+	if q.count >= q.m {
+		// Every slot is in use, so there is no slot to shift the run into
+		return unique && q.lookup(fq, fr)
+	}
+	insertSlot := newSlot(fr)
+	wasOccupied := q.slot(fq).isOccupied()
+	q.setSlot(fq, q.slot(fq).setOccupied(true))
 	start := q.scan(fq)
 	i := start
 	if wasOccupied {
 		// Search for the position in the existing run
 		for {
-			if q.slots[i].getReminder().Equal(fr) {
+			if q.slot(i).getReminder() == fr {
 				if unique {
 					return true
 				}
 				break
 			}
-			if utils.BitSetToUint(q.slots[i].getReminder()) > utils.BitSetToUint(fr) {
+			if q.slot(i).getReminder() > fr {
 				break
 			}
 			i = q.next(i)
-			if !q.slots[i].isContinuation {
+			if !q.slot(i).isContinuation() {
 				break
 			}
 		}
 		// Once having the desired position to insert the element into the run
 		if i == start {
 			// Old start of run becomes a continuation
-			q.slots[i].isContinuation = true
+			q.setSlot(i, q.slot(i).setContinuation(true))
 			// New element becomes the beginning of the run
 		} else {
 			// New element becomes a continuation
-			insertSlot.isContinuation = true
+			insertSlot = insertSlot.setContinuation(true)
 		}
 	}
 	// Set shifted bit if canonical slot is already in use or not
-	if i != fq {
-		insertSlot.isShifted = true
-	} else {
-		insertSlot.isShifted = false
-	}
+	insertSlot = insertSlot.setShifted(i != fq)
 	q.shiftRightAndInsert(i, insertSlot)
 	q.count++
 	return true
 }
 
-func (q *QuotientFilter) shiftRightAndInsert (pos uint, insertSlot *slot) {
-	var prev slot
-	curr := insertSlot.getSlot()
+func (q *QuotientFilter) shiftRightAndInsert(pos uint, insertSlot slot) {
+	curr := insertSlot
 	for {
-		prev = q.slots[pos].getSlot()
+		prev := q.slot(pos)
 		empty := prev.isEmpty()
 		if !empty {
-			prev.isShifted = true
-			if prev.isOccupied {
-				curr.isOccupied = true
-				prev.isOccupied = false
+			prev = prev.setShifted(true)
+			if prev.isOccupied() {
+				curr = curr.setOccupied(true)
+				prev = prev.setOccupied(false)
 			}
 		}
-		q.slots[pos] = curr.getSlot()
-		curr = prev.getSlot()
+		q.setSlot(pos, curr)
+		curr = prev
 		pos = q.next(pos)
 		if empty {
 			break
@@ -174,14 +234,14 @@ func (q *QuotientFilter) shiftRightAndInsert (pos uint, insertSlot *slot) {
 	}
 }
 
-func (q QuotientFilter) lookup(fq uint, fr *bitset.BitSet) bool {
-	if !q.slots[fq].isOccupied {
+func (q *QuotientFilter) lookup(fq uint, fr uint64) bool {
+	if !q.slot(fq).isOccupied() {
 		return false
 	}
 	start := q.scan(fq)
 	i := start
-	for ok := true; ok; ok = q.slots[i].isContinuation {
-		if q.slots[i].getReminder().Equal(fr) {
+	for ok := true; ok; ok = q.slot(i).isContinuation() {
+		if q.slot(i).getReminder() == fr {
 			return true
 		}
 		i = q.next(i)
@@ -189,111 +249,107 @@ func (q QuotientFilter) lookup(fq uint, fr *bitset.BitSet) bool {
 	return false
 }
 
-func (q *QuotientFilter) delete(fq uint, fr *bitset.BitSet) bool {
-	if !q.slots[fq].isOccupied {
+func (q *QuotientFilter) delete(fq uint, fr uint64) bool {
+	if !q.slot(fq).isOccupied() {
 		return false
 	}
 	start := q.scan(fq)
 	i := start
-	for ok := true; ok; ok = q.slots[i].isContinuation {
-		if q.slots[i].getReminder().Equal(fr) {
+	for ok := true; ok; ok = q.slot(i).isContinuation() {
+		if q.slot(i).getReminder() == fr {
 			break
 		}
-		if utils.BitSetToUint(q.slots[i].getReminder()) > utils.BitSetToUint(fr) {
+		if q.slot(i).getReminder() > fr {
 			return false
 		}
 		i = q.next(i)
 	}
-	if q.slots[i].isEmpty() || !q.slots[i].getReminder().Equal(fr) {
+	if q.slot(i).isEmpty() || q.slot(i).getReminder() != fr {
 		return false
 	}
 
-	wasInitRun := q.slots[i].isInitRun()
-	wasShifted := q.slots[i].isShifted
+	wasInitRun := q.slot(i).isInitRun()
+	wasShifted := q.slot(i).isShifted()
 
 	q.shiftLeft(i, fq)
 
 	if wasInitRun {
 		// If exist a continuation, it becomes init of cluster/run
-		if q.slots[i].isContinuation {
-			q.slots[i].isContinuation = false
-			q.slots[i].isShifted = wasShifted
+		if q.slot(i).isContinuation() {
+			q.setSlot(i, q.slot(i).setContinuation(false).setShifted(wasShifted))
 		} else {
-			q.slots[fq].isOccupied = false
+			q.setSlot(fq, q.slot(fq).setOccupied(false))
 		}
 	}
+	q.count--
 	return true
 }
 
 func (q *QuotientFilter) shiftLeft(pos uint, canonicalSlot uint) {
 	i := q.next(pos)
 	for {
-		if q.slots[i].isEmpty() || q.slots[i].isInitCluster() {
-			q.slots[q.prev(i)].add(nil)
-			q.slots[q.prev(i)].isContinuation = false
-			q.slots[q.prev(i)].isShifted = false
+		curr := q.slot(i)
+		if curr.isEmpty() || curr.isInitCluster() {
+			q.setSlot(q.prev(i), q.slot(q.prev(i)).delete())
 			return
 		}
-		q.slots[q.prev(i)].add(q.slots[i].getReminder())
-		q.slots[q.prev(i)].isContinuation = q.slots[i].isContinuation
-		q.slots[q.prev(i)].isShifted = q.slots[i].isShifted
+		moved := q.slot(q.prev(i)).setReminder(curr.getReminder()).setContinuation(curr.isContinuation()).setShifted(curr.isShifted())
 		// If init run, shifted bit must be unset if new slot is canonical
-		if q.slots[i].isInitRun() {
-			for ok := true; ok; ok = !q.slots[canonicalSlot].isOccupied {
+		if curr.isInitRun() {
+			for ok := true; ok; ok = !q.slot(canonicalSlot).isOccupied() {
 				canonicalSlot = q.next(canonicalSlot)
 			}
-			if q.slots[q.prev(i)].isOccupied && canonicalSlot == q.prev(i) {
-				q.slots[q.prev(i)].isShifted = false
+			if moved.isOccupied() && canonicalSlot == q.prev(i) {
+				moved = moved.setShifted(false)
 			}
 		}
+		q.setSlot(q.prev(i), moved)
 		i = q.next(i)
 	}
 }
 
 // scan run of fq such that the run is [start, end)
-func (q QuotientFilter) scan(fq uint) uint {
+func (q *QuotientFilter) scan(fq uint) uint {
 	j := fq
-	for q.slots[j].isShifted {
+	for q.slot(j).isShifted() {
 		j = q.prev(j)
 	}
 	start := j
 	for j != fq {
-		for ok := true; ok; ok = q.slots[start].isContinuation {
+		for ok := true; ok; ok = q.slot(start).isContinuation() {
 			start = q.next(start)
 		}
-		for ok := true; ok; ok = !q.slots[j].isOccupied {
+		for ok := true; ok; ok = !q.slot(j).isOccupied() {
 			j = q.next(j)
 		}
 	}
 	return start
 }
 
-func getFingerprint(element []byte) uint64 {
-	return murmur3.Sum64(element)
+func (q *QuotientFilter) getFingerprint(element []byte) uint64 {
+	return q.hasher.Sum64(element, q.seed)
 }
 
-func (q QuotientFilter) getQuotientPosAndRest(f uint64) (uint, *bitset.BitSet) {
-	fingerprint := bitset.From([]uint64{f})
-	fr := bitset.New(q.r)
-	for i := uint(0); i < q.r; i++ {
-		fr.SetTo(i, fingerprint.Test(i))
-	}
-	fq := bitset.New(q.q)
-	for i := uint(0); i < q.q; i++ {
-		pos := q.r + i
-		fq.SetTo(i, fingerprint.Test(pos))
-	}
-	return utils.BitSetToUint(fq), fr
+func (q *QuotientFilter) getQuotientPosAndRest(f uint64) (uint, uint64) {
+	fr := f & utils.Mask(q.r)
+	fq := (f >> q.r) & utils.Mask(q.q)
+	return uint(fq), fr
 }
 
-func (q QuotientFilter) next(i uint) uint {
+func (q *QuotientFilter) next(i uint) uint {
 	return (i + 1) % q.m
 }
 
-func (q QuotientFilter) prev(i uint) uint {
+func (q *QuotientFilter) prev(i uint) uint {
 	return (i - 1) % q.m
 }
 
+// falsePositiveRate returns the probability that a lookup of an element not in QF finds its reminder in the run of its quotient,
+// given the load factor of QF
+func (q *QuotientFilter) falsePositiveRate(load float64) float64 {
+	return 1 - math.Exp(-load/math.Pow(2, float64(q.r)))
+}
+
 func computeSizeQ(size uint) uint {
 	return uint(math.Ceil(math.Log2(float64(size) / loadFactor)))
 }
@@ -306,8 +362,9 @@ func computeError(n, p uint) float64 {
 	return float64(n) / math.Pow(2, float64(p))
 }
 
+// computeSizeR returns the length of the reminder, at least 1 bit
 func computeSizeR(size uint, q uint, error float64) uint {
-	return uint(math.Ceil(math.Log2(-float64(size) / (math.Pow(2, float64(q)) * math.Log(1.0-error)))))
+	return uint(math.Max(1, math.Ceil(math.Log2(-float64(size)/(math.Pow(2, float64(q))*math.Log(1.0-error))))))
 }
 
 func computeSizeM(sizeQ uint) uint {
@@ -1,11 +1,19 @@
 package quotientFilter
 
 import (
+	"ProbabilisticDataStructures/container"
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/filter/conformance"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
+	"math/rand"
+	"path/filepath"
 	"testing"
-
-	"github.com/bits-and-blooms/bitset"
 )
 
 const roundTo = 100000
@@ -155,139 +163,545 @@ func TestInsertAndLookupAndDeleteWithHighLoadFactor(t *testing.T) {
 }
 
 func TestInsertManually(t *testing.T) {
-	q := New(3, 64)
-	ok := q.insert(7, bitset.From([]uint64{71}), true)
+	q := New(3, 61)
+	ok := q.insert(7, 71, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{12}), true)
+	ok = q.insert(1, 12, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(4, bitset.From([]uint64{41}), true)
+	ok = q.insert(4, 41, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{11}), true)
+	ok = q.insert(1, 11, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(2, bitset.From([]uint64{21}), true)
+	ok = q.insert(2, 21, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(2, bitset.From([]uint64{22}), true)
+	ok = q.insert(2, 22, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{10}), true)
+	ok = q.insert(1, 10, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(3, bitset.From([]uint64{33}), true)
+	ok = q.insert(3, 33, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
 }
 
 func TestInsertManuallyAndLookup(t *testing.T) {
-	q := New(3, 64)
-	ok := q.insert(7, bitset.From([]uint64{71}), true)
+	q := New(3, 61)
+	ok := q.insert(7, 71, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{12}), true)
+	ok = q.insert(1, 12, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(4, bitset.From([]uint64{41}), true)
+	ok = q.insert(4, 41, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{11}), true)
+	ok = q.insert(1, 11, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(2, bitset.From([]uint64{21}), true)
+	ok = q.insert(2, 21, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(2, bitset.From([]uint64{22}), true)
+	ok = q.insert(2, 22, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{10}), true)
+	ok = q.insert(1, 10, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
 	//LOOKUP
-	ok = q.lookup(7, bitset.From([]uint64{71}))
+	ok = q.lookup(7, 71)
 	if !ok {
 		t.Errorf("Element should be in the filter")
 	}
-	ok = q.lookup(1, bitset.From([]uint64{12}))
+	ok = q.lookup(1, 12)
 	if !ok {
 		t.Errorf("Element should be in the filter")
 	}
-	ok = q.lookup(4, bitset.From([]uint64{41}))
+	ok = q.lookup(4, 41)
 	if !ok {
 		t.Errorf("Element should be in the filter")
 	}
-	ok = q.lookup(1, bitset.From([]uint64{11}))
+	ok = q.lookup(1, 11)
 	if !ok {
 		t.Errorf("Element should be in the filter")
 	}
-	ok = q.lookup(2, bitset.From([]uint64{21}))
+	ok = q.lookup(2, 21)
 	if !ok {
 		t.Errorf("Element should be in the filter")
 	}
-	ok = q.lookup(2, bitset.From([]uint64{22}))
+	ok = q.lookup(2, 22)
 	if !ok {
 		t.Errorf("Element should be in the filter")
 	}
-	ok = q.lookup(1, bitset.From([]uint64{10}))
+	ok = q.lookup(1, 10)
 	if !ok {
 		t.Errorf("Element should be in the filter")
 	}
 }
 
 func TestInsertManuallyAndDelete(t *testing.T) {
-	q := New(3, 64)
-	ok := q.insert(7, bitset.From([]uint64{71}), true)
+	q := New(3, 61)
+	ok := q.insert(7, 71, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{12}), true)
+	ok = q.insert(1, 12, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(4, bitset.From([]uint64{41}), true)
+	ok = q.insert(4, 41, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{11}), true)
+	ok = q.insert(1, 11, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(2, bitset.From([]uint64{21}), true)
+	ok = q.insert(2, 21, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(2, bitset.From([]uint64{22}), true)
+	ok = q.insert(2, 22, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
-	ok = q.insert(1, bitset.From([]uint64{10}), true)
+	ok = q.insert(1, 10, true)
 	if !ok {
 		t.Errorf("NOT correctly inserted in")
 	}
 	//DELETE
-	ok = q.delete(2, bitset.From([]uint64{21}))
+	ok = q.delete(2, 21)
 	if !ok {
 		t.Errorf("Element should be removed from the filter")
 	}
-	ok = q.delete(2, bitset.From([]uint64{22}))
+	ok = q.delete(2, 22)
 	if !ok {
 		t.Errorf("Element should be removed from the filter")
 	}
-}
\ No newline at end of file
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(n uint, e float64) filter.Filter {
+		f := NewFromSizeAndError(n, e)
+		return &f
+	})
+}
+
+func TestConcurrentConformance(t *testing.T) {
+	conformance.RunConcurrent(t, func(n uint, e float64) filter.Filter {
+		f := NewFromSizeAndError(n, e)
+		c := NewConcurrent(&f)
+		c.SetParallelism(4)
+		return c
+	})
+}
+
+
+func TestMarshalAndUnmarshalBinary(t *testing.T) {
+	q := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		q.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	q.Delete([]byte("0"))
+	data, err := q.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded QuotientFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.n != q.n || decoded.m != q.m || decoded.q != q.q || decoded.r != q.r || decoded.e != q.e || decoded.count != q.count {
+		t.Errorf("Expected parameters (%d, %d, %d, %d, %f, %d), Current parameters (%d, %d, %d, %d, %f, %d)", q.n, q.m, q.q, q.r, q.e, q.count, decoded.n, decoded.m, decoded.q, decoded.r, decoded.e, decoded.count)
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if q.Lookup(elem) != decoded.Lookup(elem) {
+			t.Errorf("Lookup of %s differs after decoding", elem)
+		}
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != ErrInvalidEncoding {
+		t.Errorf("Expected error %v, Current error %v", ErrInvalidEncoding, err)
+	}
+}
+
+func TestWriteToAndReadFrom(t *testing.T) {
+	q := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		q.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	var buf bytes.Buffer
+	written, err := q.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded QuotientFilter
+	read, err := decoded.ReadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != read {
+		t.Errorf("Expected %d bytes read, Current %d bytes read", written, read)
+	}
+	for i := 0; i < 1000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if ok := decoded.Lookup(elem); !ok {
+			t.Errorf("%s should be in.", elem)
+		}
+		if ok := decoded.Delete(elem); !ok {
+			t.Errorf("%s should be deleted.", elem)
+		}
+	}
+}
+
+func TestInsertAndDeleteKeepEveryRemainingElement(t *testing.T) {
+	q := New(8, 4)
+	counts := make(map[string]int)
+	keys := make([]string, 150)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%d", i)
+	}
+	inserted := 0
+	random := rand.New(rand.NewSource(1))
+	for op := 0; op < 20000; op++ {
+		key := keys[random.Intn(len(keys))]
+		if counts[key] > 0 && random.Intn(2) == 0 {
+			if ok := q.Delete([]byte(key)); !ok {
+				t.Fatalf("%s should be deleted (operation %d).", key, op)
+			}
+			counts[key]--
+			inserted--
+		} else if inserted < 150 {
+			q.Insert([]byte(key))
+			counts[key]++
+			inserted++
+		}
+		for k, c := range counts {
+			if ok := q.Lookup([]byte(k)); c > 0 && !ok {
+				t.Fatalf("%s should be in (operation %d).", k, op)
+			}
+		}
+	}
+}
+
+func TestTotalSizeMatchesPackedTable(t *testing.T) {
+	for r := uint(1); r <= 40; r++ {
+		q := New(10, r)
+		expected := (q.m*(metadataBits+r) + utils.Machine64Bits - 1) / utils.Machine64Bits * utils.WordSize
+		if size := q.TotalSize(); size != expected {
+			t.Errorf("Expected size %d (in r = %d), Current size %d", expected, r, size)
+		}
+	}
+}
+
+func TestWriteAndReadWithEveryHasher(t *testing.T) {
+	for _, h := range []hasher.Hasher{hasher.Murmur3{}, hasher.XXHash{}, hasher.FNV1a{}, hasher.NewSipHash([16]byte{1, 2, 3, 4})} {
+		f, err := NewWithOptions(1000, 0.01, WithHasher(h), WithSeed(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 1000; i++ {
+			f.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		var buf bytes.Buffer
+		if _, err := container.Write(&buf, f); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := container.Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if algorithm := stored.Descriptor().Hash; algorithm != container.HashAlgorithm(hasher.AlgorithmOf(h)) {
+			t.Errorf("Expected hash algorithm %v, Current hash algorithm %v", hasher.AlgorithmOf(h), algorithm)
+		}
+		decoded := stored.(*QuotientFilter)
+		if decoded.hasher != h {
+			t.Errorf("Expected hasher %v, Current hasher %v", h, decoded.hasher)
+		}
+		for i := 0; i < 2000; i++ {
+			elem := []byte(fmt.Sprintf("%d", i))
+			if f.Lookup(elem) != decoded.Lookup(elem) {
+				t.Errorf("%v: lookup of %s differs after decoding", hasher.AlgorithmOf(h), elem)
+			}
+		}
+	}
+}
+
+func TestOpenMmap(t *testing.T) {
+	q := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		q.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	path := filepath.Join(t.TempDir(), "qf")
+	if err := container.Save(path, &q); err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := OpenMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+	if mapped.Descriptor() != q.Descriptor() {
+		t.Errorf("Expected descriptor %+v, Current descriptor %+v", q.Descriptor(), mapped.Descriptor())
+	}
+	for i := 0; i < 2000; i++ {
+		elem := []byte(fmt.Sprintf("%d", i))
+		if q.Lookup(elem) != mapped.Lookup(elem) {
+			t.Errorf("Lookup of %s differs in the mapped filter", elem)
+		}
+	}
+	if ok := mapped.Insert([]byte("A")); ok {
+		t.Errorf("A mapped filter should NOT accept inserts")
+	}
+}
+
+func TestInsertAndDeleteWithEveryReminderSize(t *testing.T) {
+	size := 600
+	for r := uint(1); r <= 32; r++ {
+		q := New(10, r)
+		for i := 0; i < size; i++ {
+			q.Insert([]byte(fmt.Sprintf("%d", i)))
+		}
+		for i := 0; i < size; i++ {
+			if ok := q.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+				t.Errorf("%d should be in (in r = %d).", i, r)
+			}
+		}
+		for i := 0; i < size; i += 2 {
+			if ok := q.Delete([]byte(fmt.Sprintf("%d", i))); !ok {
+				t.Errorf("%d should be deleted (in r = %d).", i, r)
+			}
+		}
+		for i := 1; i < size; i += 2 {
+			if ok := q.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+				t.Errorf("%d should be in after deletes (in r = %d).", i, r)
+			}
+		}
+	}
+}
+
+func TestQuotientAndReminderUseWholeFingerprint(t *testing.T) {
+	f := uint64(0xaaaaaaaa55555555)
+	cases := []struct {
+		q, r   uint
+		fq, fr uint64
+	}{
+		{q: 32, r: 32, fq: 0xaaaaaaaa, fr: 0x55555555},
+		{q: 40, r: 24, fq: 0xaaaaaaaa55, fr: 0x555555},
+		{q: 20, r: 12, fq: 0x55555, fr: 0x555},
+	}
+	for _, c := range cases {
+		q := QuotientFilter{q: c.q, r: c.r}
+		fq, fr := q.getQuotientPosAndRest(f)
+		if uint64(fq) != c.fq || fr != c.fr {
+			t.Errorf("Expected quotient %x and reminder %x (in q = %d, r = %d), Current quotient %x and reminder %x", c.fq, c.fr, c.q, c.r, fq, fr)
+		}
+	}
+}
+
+func TestInsertAndLookupWithHighPrecision(t *testing.T) {
+	size := uint(100000)
+	e := 0.0001
+	q := NewFromSizeAndError(size, e)
+	if q.r <= 8 {
+		t.Errorf("Expected reminder wider than 8 bits, Current reminder size %d", q.r)
+	}
+	for i := uint(0); i < size; i++ {
+		q.Insert([]byte(fmt.Sprintf("%d", i)))
+	}
+	for i := uint(0); i < size; i++ {
+		if ok := q.Lookup([]byte(fmt.Sprintf("%d", i))); !ok {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+	elementsToTest := 1000000
+	falsePositives := 0
+	for i := uint(0); i < uint(elementsToTest); i++ {
+		if ok := q.Lookup([]byte(fmt.Sprintf("%d", i+size))); ok {
+			falsePositives++
+		}
+	}
+	expectedFalsePositives := int(float64(elementsToTest) * e)
+	rangeFalsePositives := int(math.Ceil(float64(expectedFalsePositives)*errorRangeFalsePositives) + 1)
+	if falsePositives-expectedFalsePositives > rangeFalsePositives {
+		t.Errorf("Error: Expected false positives are %d ± %d and current false positives are %d", expectedFalsePositives, rangeFalsePositives, falsePositives)
+	}
+}
+
+func TestItPanicsOnUnsupportedSizes(t *testing.T) {
+	for _, c := range [][2]uint{{3, 0}, {2, maxR + 1}, {33, 32}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("q = %d and r = %d should NOT be accepted", c[0], c[1])
+				}
+			}()
+			New(c[0], c[1])
+		}()
+	}
+}
+
+// customHasher is a Hasher other than the built-in ones
+type customHasher struct {
+	hasher.FNV1a
+}
+
+func TestNewWithOptionsRejectsInvalidParameters(t *testing.T) {
+	cases := []struct {
+		n    uint
+		e    float64
+		opts []Option
+	}{
+		{0, 0.01, nil},
+		{1000, 0, nil},
+		{1000, 1, nil},
+		{1000, math.NaN(), nil},
+		{1000, 0.01, []Option{WithHasher(nil)}},
+	}
+	for i, c := range cases {
+		if _, err := NewWithOptions(c.n, c.e, c.opts...); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("Case %d (n = %d and e = %v) should return an invalid parameter error, got %v", i, c.n, c.e, err)
+		}
+	}
+	for _, c := range [][2]uint{{0, 8}, {3, 0}, {2, maxR + 1}, {33, 32}} {
+		if _, err := NewWithSize(c[0], c[1]); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("q = %d and r = %d should return an invalid parameter error, got %v", c[0], c[1], err)
+		}
+	}
+}
+
+func TestNewWithOptionsAcceptsHighError(t *testing.T) {
+	q, err := NewWithOptions(1000, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.r == 0 {
+		t.Errorf("Reminder should have at least 1 bit")
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	size := uint(10000)
+	q, err := NewWithOptions(size, 0.01, WithSeed(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := NewWithOptions(size, 0.01, WithHasher(customHasher{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint(0); i < size; i++ {
+		element := []byte(fmt.Sprintf("%d", i))
+		q.Insert(element)
+		custom.Insert(element)
+	}
+	for i := uint(0); i < size; i++ {
+		element := []byte(fmt.Sprintf("%d", i))
+		if !q.Lookup(element) || !custom.Lookup(element) {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+	if _, err := custom.MarshalBinary(); !errors.Is(err, ErrUnsupportedHasher) {
+		t.Errorf("Expected %v encoding a QF with a custom hasher, got %v", ErrUnsupportedHasher, err)
+	}
+	data, err := q.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded QuotientFilter
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.seed != 42 {
+		t.Errorf("Expected seed = 42, Current seed = %d", decoded.seed)
+	}
+	for i := uint(0); i < size; i++ {
+		if !decoded.Lookup([]byte(fmt.Sprintf("%d", i))) {
+			t.Errorf("%d should be in the decoded QF.", i)
+		}
+	}
+}
+
+func TestCountFollowsInsertsAndDeletes(t *testing.T) {
+	q := NewFromSizeAndError(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		q.Insert([]byte(fmt.Sprint(i)))
+	}
+	for i := 0; i < 500; i++ {
+		q.Delete([]byte(fmt.Sprint(i)))
+	}
+	if stats := q.Stats(); stats.Count != 500 || stats.LoadFactor != 500/float64(q.m) || stats.Capacity != q.n || stats.TargetError != 0.01 {
+		t.Errorf("Expected 500 elements in %d slots, got %+v", q.m, stats)
+	}
+}
+
+func TestInsertFailsWhenEverySlotIsUsed(t *testing.T) {
+	q := New(2, 8)
+	inserted := 0
+	for i := 0; i < 100 && inserted < 4; i++ {
+		if q.Insert([]byte(fmt.Sprint(i))) {
+			inserted++
+		}
+	}
+	if q.count != 4 {
+		t.Fatalf("Expected the 4 slots to be used, got %d", q.count)
+	}
+	if q.Insert([]byte("one more")) {
+		t.Errorf("Insert should fail when every slot is used")
+	}
+	if !q.InsertUnique([]byte("0")) {
+		t.Errorf("InsertUnique of an element already in should succeed when every slot is used")
+	}
+	for i := 0; i < 4; i++ {
+		if !q.Lookup([]byte(fmt.Sprint(i))) {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+}
+
+func TestDecodingRejectsUntrustedSizes(t *testing.T) {
+	q := NewFromSizeAndError(1000, 0.01)
+	valid, err := q.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// q, r and the number of words of the table, which must neither overflow nor be allocated before reading them
+	cases := [][3]uint64{
+		{63, 1, 0},
+		{58, 4, uint64(utils.PackedWordsNeeded(1<<58, metadataBits+4))},
+	}
+	for _, c := range cases {
+		data := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint64(data[2*utils.WordSize:], 1<<c[0])
+		binary.LittleEndian.PutUint64(data[3*utils.WordSize:], c[0])
+		binary.LittleEndian.PutUint64(data[4*utils.WordSize:], c[1])
+		binary.LittleEndian.PutUint64(data[11*utils.WordSize:], c[2])
+		var decoded QuotientFilter
+		if err := decoded.UnmarshalBinary(data); err != ErrInvalidEncoding {
+			t.Errorf("q=%d: Expected error %v, Current error %v", c[0], ErrInvalidEncoding, err)
+		}
+		if _, err := decoded.ReadFrom(bytes.NewReader(data)); err == nil {
+			t.Errorf("q=%d: Reading a corrupted encoding should fail", c[0])
+		}
+	}
+}
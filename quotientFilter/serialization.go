@@ -0,0 +1,117 @@
+package quotientFilter
+
+import (
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"encoding"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	encodingVersion = uint64(4)
+	// version, n, m, q, r, e, seed, hash algorithm, the two words of its key, count and number of words of the table
+	headerWords = 12
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*QuotientFilter)(nil)
+	_ encoding.BinaryUnmarshaler = (*QuotientFilter)(nil)
+	_ io.WriterTo                = (*QuotientFilter)(nil)
+	_ io.ReaderFrom              = (*QuotientFilter)(nil)
+)
+
+var (
+	// ErrInvalidEncoding is returned when decoding data that does not represent a QF
+	ErrInvalidEncoding = errors.New("quotientFilter: invalid binary encoding")
+	// ErrUnsupportedVersion is returned when decoding data encoded with an unknown version
+	ErrUnsupportedVersion = errors.New("quotientFilter: unsupported encoding version")
+	// ErrUnsupportedHasher is returned when encoding a QF whose hash function is not one of the built-in hashers
+	ErrUnsupportedHasher = errors.New("quotientFilter: only the built-in hashers can be encoded")
+)
+
+// MarshalBinary encodes QF as a little-endian sequence of 64-bit words: the header followed by the packed table
+func (q *QuotientFilter) MarshalBinary() ([]byte, error) {
+	algorithm, key, err := hasher.Encode(q.hasher)
+	if err != nil {
+		return nil, ErrUnsupportedHasher
+	}
+	words := q.table.Words()
+	data := make([]byte, 0, (headerWords+len(words))*utils.WordSize)
+	data = utils.AppendWords(data, encodingVersion, uint64(q.n), uint64(q.m), uint64(q.q), uint64(q.r), math.Float64bits(q.e), uint64(q.seed), uint64(algorithm), key[0], key[1], uint64(q.count), uint64(len(words)))
+	return utils.AppendWords(data, words...), nil
+}
+
+// UnmarshalBinary decodes a QF previously encoded with MarshalBinary, replacing the content of q
+func (q *QuotientFilter) UnmarshalBinary(data []byte) error {
+	header, data, err := utils.ReadWords(data, headerWords)
+	if err != nil {
+		return ErrInvalidEncoding
+	}
+	filter, err := decodeHeader(header)
+	if err != nil {
+		return err
+	}
+	words, data, err := utils.ReadWords(data, header[11])
+	if err != nil || len(data) != 0 {
+		return ErrInvalidEncoding
+	}
+	filter.table = utils.PackedArrayFrom(words, metadataBits+filter.r)
+	*q = filter
+	return nil
+}
+
+// WriteTo writes the binary encoding of QF to w
+func (q *QuotientFilter) WriteTo(w io.Writer) (int64, error) {
+	data, err := q.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadFrom reads a binary encoding of a QF from r, replacing the content of q
+func (q *QuotientFilter) ReadFrom(r io.Reader) (int64, error) {
+	data, n, err := utils.ReadFullWords(r, nil, headerWords)
+	if err != nil {
+		return n, err
+	}
+	header, _, _ := utils.ReadWords(data, headerWords)
+	if _, err := decodeHeader(header); err != nil {
+		return n, err
+	}
+	data, read, err := utils.ReadFullWords(r, data, header[11])
+	n += read
+	if err != nil {
+		return n, err
+	}
+	return n, q.UnmarshalBinary(data)
+}
+
+// decodeHeader returns the QF described by header, without its table
+func decodeHeader(header []uint64) (QuotientFilter, error) {
+	if header[0] != encodingVersion {
+		return QuotientFilter{}, ErrUnsupportedVersion
+	}
+	m, sizeQ, sizeR := uint(header[2]), uint(header[3]), uint(header[4])
+	if validateSizes(sizeQ, sizeR) != nil || !utils.PackedArrayFits(m, metadataBits+sizeR) || m != computeSizeM(sizeQ) || header[6] > math.MaxUint32 || header[7] > math.MaxUint8 ||
+		header[11] != uint64(utils.PackedWordsNeeded(m, metadataBits+sizeR)) {
+		return QuotientFilter{}, ErrInvalidEncoding
+	}
+	h, err := hasher.Decode(hasher.Algorithm(header[7]), [2]uint64{header[8], header[9]})
+	if err != nil {
+		return QuotientFilter{}, ErrInvalidEncoding
+	}
+	return QuotientFilter{
+		n:      uint(header[1]),
+		m:      m,
+		q:      sizeQ,
+		r:      sizeR,
+		e:      math.Float64frombits(header[5]),
+		seed:   uint32(header[6]),
+		count:  uint(header[10]),
+		hasher: h,
+	}, nil
+}
@@ -1,59 +1,75 @@
 package quotientFilter
 
-import (
-	"github.com/bits-and-blooms/bitset"
+const (
+	occupiedBit     = slot(1) << 0
+	continuationBit = slot(1) << 1
+	shiftedBit      = slot(1) << 2
+	metadataMask    = occupiedBit | continuationBit | shiftedBit
+	// metadataBits is the number of bits of every slot before the reminder
+	metadataBits = 3
 )
 
-const defaultSize = uint(10)
+// slot is a packed slot of the QF: the occupied, continuation and shifted bits followed by the reminder
+type slot uint64
 
-type slot struct {
-	isOccupied     bool
-	isContinuation bool
-	isShifted      bool
-	reminder       *bitset.BitSet
+func newSlot(reminder uint64) slot {
+	return slot(reminder << metadataBits)
 }
 
+// isEmpty returns true if the slot holds no reminder. A used slot always has some metadata bit set
 func (s slot) isEmpty() bool {
-	return s.reminder == nil
+	return s&metadataMask == 0
 }
 
-func (s *slot) add(fq *bitset.BitSet) {
-	size := defaultSize
-	if fq != nil {
-		size = fq.Len()
-	}
-	if s.reminder == nil {
-		s.reminder = bitset.New(size)
-	}
-	if fq != nil {
-		fq.Copy(s.reminder)
-		return
-	}
-	s.reminder = nil
+func (s slot) isOccupied() bool {
+	return s&occupiedBit != 0
 }
 
-func (s slot) getSlot() slot {
-	s1 := slot{
-		isOccupied:     s.isOccupied,
-		isContinuation: s.isContinuation,
-		isShifted:      s.isShifted,
-	}
-	s1.add(s.reminder)
-	return s1
+func (s slot) isContinuation() bool {
+	return s&continuationBit != 0
 }
 
-func (s slot) getReminder() *bitset.BitSet {
-	return s.reminder
+func (s slot) isShifted() bool {
+	return s&shiftedBit != 0
 }
 
-func (s *slot) delete() {
-	s.reminder = nil
+func (s slot) getReminder() uint64 {
+	return uint64(s >> metadataBits)
+}
+
+func (s slot) setOccupied(value bool) slot {
+	return s.setBit(occupiedBit, value)
+}
+
+func (s slot) setContinuation(value bool) slot {
+	return s.setBit(continuationBit, value)
+}
+
+func (s slot) setShifted(value bool) slot {
+	return s.setBit(shiftedBit, value)
+}
+
+// setReminder returns the slot holding reminder with the metadata bits of s
+func (s slot) setReminder(reminder uint64) slot {
+	return s&metadataMask | newSlot(reminder)
+}
+
+// delete returns the slot without reminder, keeping only its occupied bit
+func (s slot) delete() slot {
+	return s & occupiedBit
+}
+
+func (s slot) setBit(bit slot, value bool) slot {
+	if value {
+		return s | bit
+	}
+	return s &^ bit
 }
 
 func (s slot) isInitCluster() bool {
-	return s.isOccupied && !s.isContinuation && !s.isShifted
+	return s.isOccupied() && !s.isContinuation() && !s.isShifted()
 }
 
 func (s slot) isInitRun() bool {
-	return s.isInitCluster() || (!s.isContinuation && s.isShifted)
+	return s.isInitCluster() || (!s.isContinuation() && s.isShifted())
 }
@@ -0,0 +1,34 @@
+package quotientFilter
+
+import "ProbabilisticDataStructures/filter"
+
+var (
+	_ filter.StatsReporter = (*QuotientFilter)(nil)
+	_ filter.StatsReporter = (*ConcurrentQuotientFilter)(nil)
+	_ filter.StatsReporter = (*MmapQuotientFilter)(nil)
+)
+
+// Stats returns the statistics of QF, whose count is exact. Computational time: O(1)
+func (q *QuotientFilter) Stats() filter.Stats {
+	load := float64(q.count) / float64(q.m)
+	return filter.Stats{
+		Count:             q.count,
+		LoadFactor:        load,
+		Capacity:          q.n,
+		TargetError:       q.e,
+		FalsePositiveRate: q.falsePositiveRate(load),
+		MemoryBytes:       q.TotalSize(),
+	}
+}
+
+// Stats returns the statistics of QF, holding the read lock. Computational time: O(1)
+func (c *ConcurrentQuotientFilter) Stats() filter.Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.filter.Stats()
+}
+
+// Stats returns the statistics of QF, whose count is the one it was saved with. Computational time: O(1)
+func (q *MmapQuotientFilter) Stats() filter.Stats {
+	return q.filter.Stats()
+}
@@ -0,0 +1,112 @@
+package stableBloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"math/rand"
+)
+
+const (
+	defaultCellBits = 1
+	maxCellBits     = 16
+	defaultRandSeed = uint64(1)
+)
+
+// Option configures a SBF created with NewWithOptions
+type Option func(*config) error
+
+type config struct {
+	seed     uint32
+	hasher   hasher.Hasher
+	cellBits uint
+	// k is computed from the false positive bound when 0
+	k      uint
+	random rand.Source
+}
+
+func newConfig(opts []Option) (config, error) {
+	cfg := config{
+		hasher:   hasher.Murmur3{},
+		cellBits: defaultCellBits,
+	}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return config{}, err
+		}
+	}
+	if cfg.random == nil {
+		cfg.random = utils.NewSplitMix64(defaultRandSeed)
+	}
+	return cfg, nil
+}
+
+// WithSeed sets the seed of the first hash function. The i-th hash function uses seed+i. The default seed is 0
+func WithSeed(seed uint32) Option {
+	return func(c *config) error {
+		c.seed = seed
+		return nil
+	}
+}
+
+// WithHasher sets the hash function used to compute the k cells of an element. The default is hasher.Murmur3
+func WithHasher(h hasher.Hasher) Option {
+	return func(c *config) error {
+		if h == nil {
+			return parameterError("hasher", h, "must not be nil")
+		}
+		c.hasher = h
+		return nil
+	}
+}
+
+// WithCellBits sets the number of bits d of every cell, between 1 and 16. Inserts set cells to 2^d-1, so wider cells keep elements longer
+// at the cost of memory. The default is 1 bit
+func WithCellBits(d uint) Option {
+	return func(c *config) error {
+		if d == 0 || d > maxCellBits {
+			return parameterError("d", d, "cells must have between 1 and 16 bits")
+		}
+		c.cellBits = d
+		return nil
+	}
+}
+
+// WithHashFunctions sets the number of hash functions k. By default it is the one of a BF with the false positive bound as error, log2(1/fps)
+func WithHashFunctions(k uint) Option {
+	return func(c *config) error {
+		if k == 0 {
+			return parameterError("k", k, "number of hash functions must be greater than 0")
+		}
+		c.k = k
+		return nil
+	}
+}
+
+// WithRandSeed seeds the pseudo-random numbers that choose the cells decremented by inserts. The default seed is 1
+func WithRandSeed(seed uint64) Option {
+	return func(c *config) error {
+		c.random = utils.NewSplitMix64(seed)
+		return nil
+	}
+}
+
+// WithRandSource sets the source of the pseudo-random numbers that choose the cells decremented by inserts
+func WithRandSource(source rand.Source) Option {
+	return func(c *config) error {
+		if source == nil {
+			return parameterError("random", source, "must not be nil")
+		}
+		c.random = source
+		return nil
+	}
+}
+
+func parameterError(parameter string, value interface{}, reason string) error {
+	return &filter.ParameterError{
+		Filter:    "stableBloomFilter",
+		Parameter: parameter,
+		Value:     value,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,149 @@
+package stableBloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"ProbabilisticDataStructures/hasher"
+	"ProbabilisticDataStructures/utils"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+var _ filter.Filter = (*StableBloomFilter)(nil)
+
+// StableBloomFilter is a Stable Bloom Filter (Deng and Rafiei): m cells of d bits where every insert sets the k cells of the element
+// to 2^d-1 after decrementing p cells chosen at random. Old elements are evicted, so the fraction of zero cells, and thus the
+// false positive rate, converges to a stable point however long the stream is, at the cost of false negatives
+type StableBloomFilter struct {
+	m      uint
+	k      uint
+	d      uint
+	p      uint
+	fps    float64
+	seed   uint32
+	hasher hasher.Hasher
+	// max is the value inserts set cells to
+	max   uint64
+	cells utils.PackedArray
+	// random chooses the cells decremented by inserts, so the same stream gives the same cells
+	random *rand.Rand
+}
+
+// New creates a new Stable Bloom Filter with m cells of d bits whose false positive rate stays below fps.
+// It panics if the parameters are not valid, NewWithOptions returns an error instead
+func New(m uint, d uint, fps float64) StableBloomFilter {
+	s, err := NewWithOptions(m, fps, WithCellBits(d))
+	if err != nil {
+		panic(err)
+	}
+	return *s
+}
+
+// NewWithOptions creates a new Stable Bloom Filter with m cells whose false positive rate stays below fps once stable, configured with opts.
+// The number of cells p decremented per insert is the lowest that keeps the false positive rate below fps
+func NewWithOptions(m uint, fps float64, opts ...Option) (*StableBloomFilter, error) {
+	if !(fps > 0 && fps < 1) {
+		return nil, parameterError("fps", fps, "false positive bound must be between 0 and 1 (exclusive)")
+	}
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.k == 0 {
+		cfg.k = computeSizeK(fps)
+	}
+	if m <= cfg.k {
+		return nil, parameterError("m", m, "number of cells must be greater than the number of hash functions")
+	}
+	max := utils.Mask(cfg.cellBits)
+	p, ok := computeSizeP(m, cfg.k, max, fps)
+	if !ok {
+		return nil, parameterError("fps", fps, fmt.Sprintf("false positive bound is unreachable with %d cells of %d bits and %d hash functions", m, cfg.cellBits, cfg.k))
+	}
+	return &StableBloomFilter{
+		m:      m,
+		k:      cfg.k,
+		d:      cfg.cellBits,
+		p:      p,
+		fps:    fps,
+		seed:   cfg.seed,
+		hasher: cfg.hasher,
+		max:    max,
+		cells:  utils.NewPackedArray(m, cfg.cellBits),
+		random: rand.New(cfg.random),
+	}, nil
+}
+
+// Insert inserts element into SBF, evicting older elements. Always returns true. Computational time: O(k+p)
+func (s *StableBloomFilter) Insert(element []byte) bool {
+	s.decrement()
+	for i := uint(0); i < s.k; i++ {
+		s.cells.Set(s.position(element, i), s.max)
+	}
+	return true
+}
+
+// Lookup returns true if element may have been inserted into SBF and false if element has not been inserted recently.
+// Elements inserted long ago may have been evicted. Computational time: O(k)
+func (s *StableBloomFilter) Lookup(element []byte) bool {
+	for i := uint(0); i < s.k; i++ {
+		if s.cells.Get(s.position(element, i)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAndAdd inserts element into SBF and returns true if element may have been inserted before, so duplicates in a stream are detected
+// in one call. Computational time: O(k+p)
+func (s *StableBloomFilter) TestAndAdd(element []byte) bool {
+	seen := s.Lookup(element)
+	s.Insert(element)
+	return seen
+}
+
+// TotalSize returns the size (in bytes) of the packed cells that represent SBF, that is m*d bits rounded up to words.
+func (s *StableBloomFilter) TotalSize() uint {
+	return uint(len(s.cells.Words())) * utils.WordSize
+}
+
+// StableFalsePositiveRate returns the false positive rate SBF converges to, which is at most the bound it was created with
+func (s *StableBloomFilter) StableFalsePositiveRate() float64 {
+	return stableFalsePositiveRate(s.m, s.k, s.p, s.max)
+}
+
+// decrement decrements p consecutive cells starting at a random one, wrapping around the end
+func (s *StableBloomFilter) decrement() {
+	start := uint(s.random.Int63n(int64(s.m)))
+	for i := uint(0); i < s.p; i++ {
+		pos := (start + i) % s.m
+		if cell := s.cells.Get(pos); cell > 0 {
+			s.cells.Set(pos, cell-1)
+		}
+	}
+}
+
+func (s *StableBloomFilter) position(element []byte, i uint) uint {
+	return uint(s.hasher.Sum64(element, s.seed+uint32(i)) % uint64(s.m))
+}
+
+// stableFalsePositiveRate returns (1-z)^k, z being the fraction of zero cells at the stable point, (1/(1+1/(p*(1/k-1/m))))^max
+func stableFalsePositiveRate(m uint, k uint, p uint, max uint64) float64 {
+	zeros := math.Pow(1/(1+1/(float64(p)*(1/float64(k)-1/float64(m)))), float64(max))
+	return math.Pow(1-zeros, float64(k))
+}
+
+func computeSizeK(fps float64) uint {
+	return uint(math.Max(1, math.Ceil(math.Log2(1/fps))))
+}
+
+// computeSizeP returns the lowest number of cells to decrement per insert whose stable false positive rate is at most fps.
+// Returns false if even decrementing the m cells on every insert does not keep it below fps
+func computeSizeP(m uint, k uint, max uint64, fps float64) (uint, bool) {
+	zeros := 1 - math.Pow(fps, 1/float64(k))
+	p := math.Ceil(1 / ((1/math.Pow(zeros, 1/float64(max)) - 1) * (1/float64(k) - 1/float64(m))))
+	if !(p <= float64(m)) {
+		return 0, false
+	}
+	return uint(math.Max(1, p)), true
+}
@@ -0,0 +1,123 @@
+package stableBloomFilter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+const (
+	streamLength = 2000000
+	// window is the number of elements whose false positive and false negative rates are measured together
+	window = 100000
+	// duplicateRate is the probability that an element of the stream repeats one of the last recent distinct elements
+	duplicateRate = 0.5
+	recent        = 10000
+)
+
+func TestFPRateAndFNRateOverStream(t *testing.T) {
+	m := uint(200000)
+	fps := 0.01
+	var results [][]string
+	for _, d := range []uint{1, 2, 3} {
+		s := New(m, d, fps)
+		random := rand.New(rand.NewSource(1))
+		distinct := 0
+		var falsePositives, falseNegatives, news, duplicates int
+		for i := 1; i <= streamLength; i++ {
+			var elem []byte
+			duplicate := distinct > 0 && random.Float64() < duplicateRate
+			if duplicate {
+				// Repeat one of the last recent distinct elements
+				back := random.Intn(recent)
+				if back >= distinct {
+					back = distinct - 1
+				}
+				elem = []byte(fmt.Sprint(distinct - 1 - back))
+				duplicates++
+			} else {
+				elem = []byte(fmt.Sprint(distinct))
+				distinct++
+				news++
+			}
+			seen := s.TestAndAdd(elem)
+			if duplicate && !seen {
+				falseNegatives++
+			}
+			if !duplicate && seen {
+				falsePositives++
+			}
+			if i%window == 0 {
+				results = append(results, []string{fmt.Sprint(d), fmt.Sprint(i), fmt.Sprint(float64(falsePositives) / float64(news)),
+					fmt.Sprint(float64(falseNegatives) / float64(duplicates)), fmt.Sprint(s.StableFalsePositiveRate())})
+				falsePositives, falseNegatives, news, duplicates = 0, 0, 0, 0
+			}
+		}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/StableBF_Stream_m:%d.csv", m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"d", "inserted", "fp", "fn", "stable_fp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
+
+func TestFPRateInFunctionOfBound(t *testing.T) {
+	m := uint(200000)
+	var results [][]string
+	for _, fps := range []float64{0.1, 0.05, 0.01, 0.005, 0.001} {
+		for _, d := range []uint{1, 2, 3} {
+			s := New(m, d, fps)
+			for i := 0; i < streamLength; i++ {
+				s.Insert([]byte(fmt.Sprint(i)))
+			}
+			falsePositives := 0
+			for i := streamLength; i < streamLength+window; i++ {
+				if s.Lookup([]byte(fmt.Sprint(i))) {
+					falsePositives++
+				}
+			}
+			rate := float64(falsePositives) / window
+			if rate > 1.2*fps {
+				t.Errorf("Error (fps = %v, d = %d): Expected false positive rate at most %v and current rate is %v", fps, d, fps, rate)
+			}
+			results = append(results, []string{fmt.Sprint(fps), fmt.Sprint(d), fmt.Sprint(s.k), fmt.Sprint(s.p), fmt.Sprint(rate), fmt.Sprint(s.StableFalsePositiveRate())})
+		}
+	}
+	resultsFile, err := os.Create(fmt.Sprintf("../results/StableBF_FP_m:%d.csv", m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := csv.NewWriter(resultsFile)
+
+	//Title
+	err = w.Write([]string{"fps", "d", "k", "p", "fp", "stable_fp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, elem := range results {
+		err = w.Write(elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	resultsFile.Close()
+}
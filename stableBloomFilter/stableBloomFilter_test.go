@@ -0,0 +1,123 @@
+package stableBloomFilter
+
+import (
+	"ProbabilisticDataStructures/filter"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	s := New(10000, 1, 0.01)
+	if s.k != 7 || s.max != 1 || s.d != 1 {
+		t.Errorf("Expected k = 7 and 1-bit cells, got k = %d and %d-bit cells", s.k, s.d)
+	}
+	if fps := s.StableFalsePositiveRate(); fps > 0.01 {
+		t.Errorf("Expected stable false positive rate at most 0.01, got %v", fps)
+	}
+	// The previous p is the highest that does not keep the rate below the bound
+	if fps := stableFalsePositiveRate(s.m, s.k, s.p-1, s.max); fps <= 0.01 {
+		t.Errorf("Expected p = %d to be the lowest with false positive rate at most 0.01, but p = %d gives %v", s.p, s.p-1, fps)
+	}
+	if s.TotalSize() != 157*8 {
+		t.Errorf("Expected 10000 1-bit cells to take 157 words, got %d bytes", s.TotalSize())
+	}
+}
+
+func TestNewWithOptionsRejectsInvalidParameters(t *testing.T) {
+	cases := []struct {
+		m    uint
+		fps  float64
+		opts []Option
+	}{
+		{1000, 0, nil},
+		{1000, 1, nil},
+		{1000, math.NaN(), nil},
+		{7, 0.01, nil},
+		{1000, 0.01, []Option{WithCellBits(0)}},
+		{1000, 0.01, []Option{WithCellBits(17)}},
+		{20, 0.01, []Option{WithCellBits(3)}},
+		{100, 0.001, []Option{WithCellBits(3)}},
+		{1000, 0.01, []Option{WithHashFunctions(0)}},
+		{1000, 0.01, []Option{WithHasher(nil)}},
+		{1000, 0.01, []Option{WithRandSource(nil)}},
+	}
+	for _, c := range cases {
+		if _, err := NewWithOptions(c.m, c.fps, c.opts...); !errors.Is(err, filter.ErrInvalidParameter) {
+			t.Errorf("m = %d and fps = %v should return an invalid parameter error, got %v", c.m, c.fps, err)
+		}
+	}
+}
+
+func TestTestAndAdd(t *testing.T) {
+	s := New(100000, 3, 0.01)
+	elem := []byte("Hello World")
+	if s.TestAndAdd(elem) {
+		t.Errorf("%s should not be seen before inserting it", elem)
+	}
+	if !s.TestAndAdd(elem) {
+		t.Errorf("%s should be seen after inserting it", elem)
+	}
+}
+
+func TestRecentElementsAreFound(t *testing.T) {
+	s := New(100000, 3, 0.01)
+	for i := 0; i < 1000000; i++ {
+		s.Insert([]byte(fmt.Sprint(i)))
+	}
+	// The last inserted elements have had no time to be evicted
+	for i := 1000000 - 100; i < 1000000; i++ {
+		if !s.Lookup([]byte(fmt.Sprint(i))) {
+			t.Errorf("%d should be in.", i)
+		}
+	}
+}
+
+func TestFalsePositiveRateConvergesToStablePoint(t *testing.T) {
+	for _, d := range []uint{1, 2, 4} {
+		s := New(50000, d, 0.02)
+		for i := 0; i < 1000000; i++ {
+			s.Insert([]byte(fmt.Sprint(i)))
+		}
+		falsePositives := 0
+		for i := 0; i < 100000; i++ {
+			if s.Lookup([]byte(fmt.Sprintf("never-%d", i))) {
+				falsePositives++
+			}
+		}
+		rate := float64(falsePositives) / 100000
+		// The stable point is an approximation, as the cells are correlated
+		if rate > 1.2*0.02 {
+			t.Errorf("d = %d: expected false positive rate at most %v, got %v", d, 0.02, rate)
+		}
+		if stable := s.StableFalsePositiveRate(); math.Abs(rate-stable) > 0.5*stable {
+			t.Errorf("d = %d: expected false positive rate around the stable %v, got %v", d, stable, rate)
+		}
+	}
+}
+
+func TestSameStreamGivesTheSameCells(t *testing.T) {
+	cells := func(randSeed uint64) []uint64 {
+		s, err := NewWithOptions(1000, 0.01, WithCellBits(3), WithRandSeed(randSeed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5000; i++ {
+			s.Insert([]byte(fmt.Sprint(i)))
+		}
+		return s.cells.Words()
+	}
+	a, b, other := cells(7), cells(7), cells(8)
+	same, sameAsOther := true, true
+	for w := range a {
+		same = same && a[w] == b[w]
+		sameAsOther = sameAsOther && a[w] == other[w]
+	}
+	if !same {
+		t.Errorf("The same stream with the same seed should decrement the same cells")
+	}
+	if sameAsOther {
+		t.Errorf("Different seeds should decrement different cells")
+	}
+}
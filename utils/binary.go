@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"unsafe"
+)
+
+// WordSize is the size (in bytes) of every word of the binary encodings
+const WordSize = 8
+
+var (
+	// ErrShortData is returned when a binary encoding ends before all its words have been read
+	ErrShortData = errors.New("utils: binary data is too short")
+	// ErrUnalignedData is returned when words cannot be read in place from a binary encoding
+	ErrUnalignedData = errors.New("utils: binary data cannot be aliased as words")
+	// ErrTooManyWords is returned when the words to read do not fit in memory
+	ErrTooManyWords = errors.New("utils: too many words to read")
+)
+
+// readChunk is the most bytes ReadFullWords allocates before reading them, so a corrupted count allocates no more memory than
+// the data actually read
+const readChunk = 1 << 20
+
+// AppendWords appends the little-endian encoding of words to buf
+func AppendWords(buf []byte, words ...uint64) []byte {
+	var w [WordSize]byte
+	for _, word := range words {
+		binary.LittleEndian.PutUint64(w[:], word)
+		buf = append(buf, w[:]...)
+	}
+	return buf
+}
+
+// ReadWords decodes count little-endian words from data. Returns the words and the remaining data
+func ReadWords(data []byte, count uint64) ([]uint64, []byte, error) {
+	if uint64(len(data))/WordSize < count {
+		return nil, nil, ErrShortData
+	}
+	words := make([]uint64, count)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(data[i*WordSize:])
+	}
+	return words, data[count*WordSize:], nil
+}
+
+// ReadFullWords reads exactly count little-endian words from r and appends their encoding to buf
+func ReadFullWords(r io.Reader, buf []byte, count uint64) ([]byte, int64, error) {
+	if count > uint64(math.MaxInt-len(buf))/WordSize {
+		return buf, 0, ErrTooManyWords
+	}
+	n := int64(0)
+	for remaining := int(count * WordSize); remaining > 0; {
+		chunk := remaining
+		if chunk > readChunk {
+			chunk = readChunk
+		}
+		start := len(buf)
+		buf = append(buf, make([]byte, chunk)...)
+		read, err := io.ReadFull(r, buf[start:])
+		n += int64(read)
+		if err == io.EOF && n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return buf, n, err
+		}
+		remaining -= chunk
+	}
+	return buf, n, nil
+}
+
+// AliasWords reinterprets data, holding little-endian words, as a slice of words without copying it.
+// Fails if data is not word aligned or if the machine is not little-endian
+func AliasWords(data []byte) ([]uint64, error) {
+	if len(data) == 0 {
+		return []uint64{}, nil
+	}
+	if len(data)%WordSize != 0 || uintptr(unsafe.Pointer(&data[0]))%WordSize != 0 || !isLittleEndian() {
+		return nil, ErrUnalignedData
+	}
+	return unsafe.Slice((*uint64)(unsafe.Pointer(&data[0])), len(data)/WordSize), nil
+}
+
+func isLittleEndian() bool {
+	word := uint16(1)
+	return *(*byte)(unsafe.Pointer(&word)) == 1
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReadFullWords(t *testing.T) {
+	data := AppendWords(nil, 1, 2, 3)
+	buf, n, err := ReadFullWords(bytes.NewReader(data), []byte{0}, 3)
+	if err != nil || n != int64(len(data)) || !bytes.Equal(buf[1:], data) {
+		t.Errorf("Expected %d bytes read, Current %d bytes read (error %v)", len(data), n, err)
+	}
+	// A count larger than the data fails once the data ends, without allocating the words first
+	if _, _, err := ReadFullWords(bytes.NewReader(data), nil, 1<<56); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected error %v, Current error %v", io.ErrUnexpectedEOF, err)
+	}
+	if _, _, err := ReadFullWords(bytes.NewReader(nil), nil, 1); err != io.EOF {
+		t.Errorf("Expected error %v, Current error %v", io.EOF, err)
+	}
+	if _, _, err := ReadFullWords(bytes.NewReader(data), nil, 1<<62); err != ErrTooManyWords {
+		t.Errorf("Expected error %v, Current error %v", ErrTooManyWords, err)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package utils
+
+import "errors"
+
+// ErrMmapUnsupported is returned when memory mapping files is not supported by the platform
+var ErrMmapUnsupported = errors.New("utils: mmap is not supported on this platform")
+
+// Mmap maps the whole file at path as read-only shared memory, so its pages are shared across processes
+func Mmap(path string) ([]byte, error) {
+	return nil, ErrMmapUnsupported
+}
+
+// Munmap unmaps memory previously mapped with Mmap
+func Munmap(data []byte) error {
+	return ErrMmapUnsupported
+}
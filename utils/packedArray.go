@@ -0,0 +1,79 @@
+package utils
+
+import "math"
+
+// PackedArray is an array of unsigned integers of a fixed width (1 to 64 bits) packed contiguously in words
+type PackedArray struct {
+	width uint
+	mask  uint64
+	words []uint64
+}
+
+// NewPackedArray creates a zeroed PackedArray holding length integers of width bits
+func NewPackedArray(length uint, width uint) PackedArray {
+	return PackedArrayFrom(make([]uint64, PackedWordsNeeded(length, width)), width)
+}
+
+// PackedArrayFrom creates a PackedArray of width bits backed by words, without copying them
+func PackedArrayFrom(words []uint64, width uint) PackedArray {
+	return PackedArray{
+		width: width,
+		mask:  Mask(width),
+		words: words,
+	}
+}
+
+// maxPackedBits is the most bits a PackedArray can span, so its length in bits, words and bytes fits in an int
+const maxPackedBits = uint(math.MaxInt) - Machine64Bits
+
+// PackedArrayFits returns true if length integers of width bits can be held in memory, so PackedWordsNeeded does not overflow.
+// Decoders check it before trusting sizes read from an encoding
+func PackedArrayFits(length uint, width uint) bool {
+	return width != 0 && length <= maxPackedBits/width
+}
+
+// PackedWordsNeeded returns the number of words needed to hold length integers of width bits
+func PackedWordsNeeded(length uint, width uint) uint {
+	return (length*width + Machine64Bits - 1) / Machine64Bits
+}
+
+// Mask returns a word with the width lowest bits set
+func Mask(width uint) uint64 {
+	if width >= Machine64Bits {
+		return ^uint64(0)
+	}
+	return (uint64(1) << width) - 1
+}
+
+// Get returns the integer at position i
+func (a PackedArray) Get(i uint) uint64 {
+	bit := i * a.width
+	w, offset := bit/Machine64Bits, bit%Machine64Bits
+	value := a.words[w] >> offset
+	if offset+a.width > Machine64Bits {
+		value |= a.words[w+1] << (Machine64Bits - offset)
+	}
+	return value & a.mask
+}
+
+// Set stores the width lowest bits of value at position i
+func (a PackedArray) Set(i uint, value uint64) {
+	value &= a.mask
+	bit := i * a.width
+	w, offset := bit/Machine64Bits, bit%Machine64Bits
+	a.words[w] = a.words[w]&^(a.mask<<offset) | value<<offset
+	if offset+a.width > Machine64Bits {
+		shift := Machine64Bits - offset
+		a.words[w+1] = a.words[w+1]&^(a.mask>>shift) | value>>shift
+	}
+}
+
+// Width returns the width (in bits) of every integer
+func (a PackedArray) Width() uint {
+	return a.width
+}
+
+// Words returns the words backing the array
+func (a PackedArray) Words() []uint64 {
+	return a.words
+}
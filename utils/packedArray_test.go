@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPackedArraySetAndGet(t *testing.T) {
+	for width := uint(1); width <= Machine64Bits; width++ {
+		length := uint(200)
+		a := NewPackedArray(length, width)
+		if words := uint(len(a.Words())); words != PackedWordsNeeded(length, width) {
+			t.Errorf("Expected %d words (in width = %d), Current words %d", PackedWordsNeeded(length, width), width, words)
+		}
+		expected := make([]uint64, length)
+		for i := range expected {
+			expected[i] = rand.Uint64() & Mask(width)
+			a.Set(uint(i), expected[i])
+		}
+		// Overwrite every other value to check neighbours are preserved
+		for i := 0; i < len(expected); i += 2 {
+			expected[i] = rand.Uint64() & Mask(width)
+			a.Set(uint(i), expected[i])
+		}
+		for i, value := range expected {
+			if current := a.Get(uint(i)); current != value {
+				t.Errorf("Expected value %d at %d (in width = %d), Current value %d", value, i, width, current)
+			}
+		}
+	}
+}
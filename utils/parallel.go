@@ -0,0 +1,29 @@
+package utils
+
+import "sync"
+
+// ParallelChunks splits [0, n) in up to goroutines consecutive chunks and calls work on every chunk from its own goroutine,
+// waiting for all of them. With fewer than 2 goroutines it calls work(0, n) directly
+func ParallelChunks(n int, goroutines int, work func(start int, end int)) {
+	if goroutines < 2 || n < 2 {
+		work(0, n)
+		return
+	}
+	if goroutines > n {
+		goroutines = n
+	}
+	size := (n + goroutines - 1) / goroutines
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start int, end int) {
+			defer wg.Done()
+			work(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
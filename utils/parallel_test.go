@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelChunksVisitsEveryIndexOnce(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 100, 1001} {
+		for _, goroutines := range []int{0, 1, 3, 8, 2000} {
+			visits := make([]int32, n)
+			ParallelChunks(n, goroutines, func(start int, end int) {
+				for i := start; i < end; i++ {
+					atomic.AddInt32(&visits[i], 1)
+				}
+			})
+			for i, v := range visits {
+				if v != 1 {
+					t.Errorf("n = %d and %d goroutines: index %d visited %d times", n, goroutines, i, v)
+				}
+			}
+		}
+	}
+}
+
+func TestRegionSortGroupsKeysByRegion(t *testing.T) {
+	keys := []uint64{1000, 3, 517, 999, 4, 0, 512, 700}
+	shift := RegionShift(1023, 4)
+	if shift != 8 {
+		t.Fatalf("RegionShift(1023, 4) = %d, want 8", shift)
+	}
+	sorted := make([]uint64, len(keys))
+	RegionSort(keys, sorted, shift, make([]int, 5))
+	want := []uint64{3, 4, 0, 517, 512, 700, 1000, 999}
+	for i := range want {
+		if sorted[i] != want[i] {
+			t.Fatalf("RegionSort = %v, want %v", sorted, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import "math/rand"
+
+var _ rand.Source64 = (*SplitMix64)(nil)
+
+// SplitMix64 is a rand.Source64 (Steele, Lea and Flood) whose whole state is a word, so filters drawing random numbers
+// can encode it and resume the same sequence after being decoded. It is not safe for concurrent use
+type SplitMix64 struct {
+	state uint64
+}
+
+// NewSplitMix64 returns a SplitMix64 seeded with seed
+func NewSplitMix64(seed uint64) *SplitMix64 {
+	return &SplitMix64{state: seed}
+}
+
+// Uint64 returns the next pseudo-random 64-bit value
+func (s *SplitMix64) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns the next pseudo-random non-negative 63-bit value
+func (s *SplitMix64) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed restarts the sequence from seed
+func (s *SplitMix64) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// State returns the state of the sequence. NewSplitMix64(s.State()) continues it where s is
+func (s *SplitMix64) State() uint64 {
+	return s.state
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "testing"
+
+func TestSplitMix64(t *testing.T) {
+	// First output of the reference implementation seeded with 0
+	if v := NewSplitMix64(0).Uint64(); v != 0xe220a8397b1dcdaf {
+		t.Errorf("Expected 0xe220a8397b1dcdaf, Current %#x", v)
+	}
+	s := NewSplitMix64(42)
+	for i := 0; i < 10; i++ {
+		s.Uint64()
+	}
+	resumed := NewSplitMix64(s.State())
+	for i := 0; i < 10; i++ {
+		if a, b := s.Int63(), resumed.Int63(); a != b || a < 0 {
+			t.Fatalf("Expected the same non-negative sequence after resuming from the state, Current %d and %d", a, b)
+		}
+	}
+}
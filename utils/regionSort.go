@@ -0,0 +1,29 @@
+package utils
+
+// RegionShift returns the smallest shift that maps every key up to max to one of regions regions
+func RegionShift(max uint64, regions int) uint {
+	shift := uint(0)
+	for max>>shift >= uint64(regions) {
+		shift++
+	}
+	return shift
+}
+
+// RegionSort copies keys into sorted grouped by region, the key shifted right by shift, in increasing order of region.
+// It is a single-pass counting sort that keeps the order of the keys inside every region. counts must have regions+1 entries
+// and sorted at least as many as keys
+func RegionSort(keys []uint64, sorted []uint64, shift uint, counts []int) {
+	for r := range counts {
+		counts[r] = 0
+	}
+	for _, key := range keys {
+		counts[key>>shift+1]++
+	}
+	for r := 1; r < len(counts); r++ {
+		counts[r] += counts[r-1]
+	}
+	for _, key := range keys {
+		sorted[counts[key>>shift]] = key
+		counts[key>>shift]++
+	}
+}
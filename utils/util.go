@@ -7,8 +7,6 @@ import (
 	"math/bits"
 	"math/rand"
 	"os"
-
-	"github.com/bits-and-blooms/bitset"
 )
 
 const Machine64Bits = 64
@@ -21,16 +19,13 @@ func NextPowerOf2(i uint) uint {
 	return uint(math.Pow(2, math.Ceil(math.Log2(float64(i)))))
 }
 
-func BitSetToUint(set *bitset.BitSet) uint {
-	return uint(set.Bytes()[0])
-}
-
 func RunningIn64BitMachine() bool {
 	return bits.UintSize == Machine64Bits
 }
 
-func Sample(i uint, j uint) uint {
-	if rand.Int()%100 < 50 {
+// Sample returns i or j with the same probability, drawing from r
+func Sample(r rand.Source, i uint, j uint) uint {
+	if r.Int63()&1 == 0 {
 		return i
 	}
 	return j